@@ -0,0 +1,214 @@
+package interceptors
+
+import (
+	"context"
+	"crypto/subtle"
+	"product-crud/pkg/logger"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// publicServices are reachable without credentials so probes and tooling keep working
+var publicServices = []string{"/grpc.health.v1.Health/", "/grpc.reflection."}
+
+// requestIDHeader is the metadata key carrying the request ID, matching the X-Request-ID HTTP header
+const requestIDHeader = "x-request-id"
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the request ID assigned by the RequestID interceptors
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// UnaryRequestID reuses the caller's x-request-id or generates one, and echoes it in the response headers
+func UnaryRequestID() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(withRequestID(ctx), req)
+	}
+}
+
+func StreamRequestID() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: withRequestID(ss.Context())})
+	}
+}
+
+// UnaryLogging gives the handler a child of base carrying the request ID, trace ID and
+// method, retrievable with logger.FromContext, and logs the call once it returns
+func UnaryLogging(base *logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		ctx = withLogger(ctx, base, info.FullMethod)
+
+		resp, err := handler(ctx, req)
+
+		logCall(ctx, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func StreamLogging(base *logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := withLogger(ss.Context(), base, info.FullMethod)
+
+		err := handler(srv, &wrappedStream{ServerStream: ss, ctx: ctx})
+
+		logCall(ctx, info.FullMethod, start, err)
+		return err
+	}
+}
+
+// UnaryRecovery turns a panicking handler into an Internal error instead of crashing the server
+func UnaryRecovery(base *logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				base.Ctx(ctx).Error("Recover from panic",
+					logger.Any("error", r),
+					logger.String("method", info.FullMethod),
+				)
+				err = status.Error(codes.Internal, "internal server error")
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+func StreamRecovery(base *logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				base.Ctx(ss.Context()).Error("Recover from panic",
+					logger.Any("error", r),
+					logger.String("method", info.FullMethod),
+				)
+				err = status.Error(codes.Internal, "internal server error")
+			}
+		}()
+
+		return handler(srv, ss)
+	}
+}
+
+// UnaryAuth requires an "authorization: Bearer <token>" header matching one of tokens.
+// With no tokens configured every call is allowed.
+func UnaryAuth(tokens []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := authorize(ctx, info.FullMethod, tokens); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func StreamAuth(tokens []string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(ss.Context(), info.FullMethod, tokens); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func withRequestID(ctx context.Context) context.Context {
+	requestID := metadataValue(ctx, requestIDHeader)
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, requestID))
+
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// withLogger stores in ctx a child of base carrying the request ID, the trace ID from
+// the traceparent metadata if any, and the method as the route
+func withLogger(ctx context.Context, base *logger.Logger, method string) context.Context {
+	fields := []logger.Field{
+		logger.String("request_id", RequestIDFromContext(ctx)),
+		logger.String("route", method),
+	}
+	if traceID := logger.TraceID(metadataValue(ctx, "traceparent")); traceID != "" {
+		fields = append(fields, logger.String("trace_id", traceID))
+	}
+
+	return logger.NewContext(ctx, base.With(fields...))
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	remoteAddr := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+
+	logger.FromContext(ctx).Info("gRPC Request",
+		logger.String("method", method),
+		logger.String("code", status.Code(err).String()),
+		logger.Duration("duration", time.Since(start)),
+		logger.String("user_agent", metadataValue(ctx, "user-agent")),
+		logger.String("remote_addr", remoteAddr))
+}
+
+// metadataValue returns the first value of an incoming metadata key, or ""
+func metadataValue(ctx context.Context, key string) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+func authorize(ctx context.Context, method string, tokens []string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	for _, prefix := range publicServices {
+		if strings.HasPrefix(method, prefix) {
+			return nil
+		}
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "missing credentials")
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return status.Error(codes.Unauthenticated, "missing credentials")
+	}
+
+	token, found := strings.CutPrefix(values[0], "Bearer ")
+	if !found {
+		return status.Error(codes.Unauthenticated, "expected a bearer token")
+	}
+
+	for _, allowed := range tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(allowed)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid token")
+}
+
+// wrappedStream overrides the context of a server stream
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *wrappedStream) Context() context.Context {
+	return s.ctx
+}
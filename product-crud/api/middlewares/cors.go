@@ -0,0 +1,197 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Defaults of a CORSConfig leaving the corresponding list empty
+var (
+	DefaultCORSMethods        = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	DefaultCORSHeaders        = []string{"Content-Type", "Authorization", "X-Request-ID", "X-Primary-Until", "traceparent", "If-Match", "If-None-Match"}
+	DefaultCORSExposedHeaders = []string{"X-Request-ID", "ETag", "X-Primary-Until"}
+)
+
+// CORSConfig describes which browser origins may call the API and how.
+//
+// AllowedOrigins entries are either "*", allowing any origin, an origin such as
+// "https://admin.example.com", where "*" matches a single DNS label, as in
+// "https://*.example.com", or a regular expression between slashes, such as
+// "/https://admin-[a-z]+\.example\.com/", which must match the whole origin. Any origin
+// is allowed when it is empty.
+type CORSConfig struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies and Authorization headers. Origins are
+	// then echoed back, never answered with "*", so the allowlist cannot be "*".
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response; zero leaves it to them
+	MaxAge time.Duration
+}
+
+// CORSPolicy is a validated CORSConfig, ready to answer requests
+type CORSPolicy struct {
+	anyOrigin        bool
+	origins          []*regexp.Regexp
+	allowMethods     string
+	allowHeaders     string
+	exposeHeaders    string
+	allowCredentials bool
+	maxAge           string
+}
+
+// NewCORSPolicy validates cfg, filling in the defaults
+func NewCORSPolicy(cfg CORSConfig) (*CORSPolicy, error) {
+	p := &CORSPolicy{
+		allowMethods:     strings.Join(orDefault(cfg.AllowedMethods, DefaultCORSMethods), ", "),
+		allowHeaders:     strings.Join(orDefault(cfg.AllowedHeaders, DefaultCORSHeaders), ", "),
+		exposeHeaders:    strings.Join(orDefault(cfg.ExposedHeaders, DefaultCORSExposedHeaders), ", "),
+		allowCredentials: cfg.AllowCredentials,
+	}
+	if cfg.MaxAge < 0 {
+		return nil, errors.New("CORS max age cannot be negative")
+	}
+	if cfg.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+
+	if len(cfg.AllowedOrigins) == 0 || slices.Contains(cfg.AllowedOrigins, "*") {
+		if cfg.AllowCredentials {
+			return nil, errors.New("CORS credentials need an explicit origin allowlist, not *")
+		}
+		p.anyOrigin = true
+		return p, nil
+	}
+
+	for _, origin := range cfg.AllowedOrigins {
+		re, err := originPattern(origin)
+		if err != nil {
+			return nil, err
+		}
+		p.origins = append(p.origins, re)
+	}
+	return p, nil
+}
+
+// originPattern compiles an AllowedOrigins entry into a regular expression matching
+// whole origins only
+func originPattern(origin string) (*regexp.Regexp, error) {
+	if len(origin) > 2 && strings.HasPrefix(origin, "/") && strings.HasSuffix(origin, "/") {
+		// Unanchored, "https://app\.example\.com" would also allow https://app.example.com.evil.net
+		re, err := regexp.Compile("^(?:" + origin[1:len(origin)-1] + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid CORS origin pattern %q: %w", origin, err)
+		}
+		return re, nil
+	}
+
+	parts := strings.Split(strings.ToLower(strings.TrimSuffix(origin, "/")), "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	// One label only, so the wildcard cannot reach past a dot or into a path such as
+	// https://evil.com/.example.com
+	return regexp.MustCompile("^" + strings.Join(parts, "[^/.]*") + "$"), nil
+}
+
+func orDefault(values, defaults []string) []string {
+	if len(values) == 0 {
+		return defaults
+	}
+	return values
+}
+
+// allows reports whether requests from origin are allowed
+func (p *CORSPolicy) allows(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	for _, re := range p.origins {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// CORSRules picks the policy of a request: the one registered for the longest prefix of
+// its path in Groups, such as "/api/v1/admin", or else Default
+type CORSRules struct {
+	Default *CORSPolicy
+	Groups  map[string]*CORSPolicy
+}
+
+func (r CORSRules) policy(path string) *CORSPolicy {
+	policy, longest := r.Default, -1
+	for prefix, p := range r.Groups {
+		if len(prefix) > longest && (path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/")) {
+			policy, longest = p, len(prefix)
+		}
+	}
+	return policy
+}
+
+// CORSMiddleware applies the CORS policy of each request's route group. It answers
+// preflight requests itself, refusing those from origins the policy does not allow; other
+// requests from such origins are served without CORS headers, so browsers hide the
+// response. It must be installed on the engine, as preflight requests match no route.
+func CORSMiddleware(rules CORSRules) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Responses depend on the origin even when it is refused, so caches must key on it
+		c.Writer.Header().Add("Vary", "Origin")
+
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		policy := rules.policy(c.Request.URL.Path)
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if !policy.allows(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		if policy.anyOrigin {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if policy.allowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+			header.Set("Access-Control-Allow-Methods", policy.allowMethods)
+			header.Set("Access-Control-Allow-Headers", policy.allowHeaders)
+			if policy.maxAge != "" {
+				header.Set("Access-Control-Max-Age", policy.maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		header.Set("Access-Control-Expose-Headers", policy.exposeHeaders)
+		c.Next()
+	}
+}
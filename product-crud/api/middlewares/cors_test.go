@@ -0,0 +1,49 @@
+package middlewares
+
+import "testing"
+
+func TestCORSPolicyAllows(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed string
+		origin  string
+		want    bool
+	}{
+		{name: "exact origin", allowed: "https://app.example.com", origin: "https://app.example.com", want: true},
+		{name: "exact origin, case-insensitively", allowed: "https://app.example.com", origin: "HTTPS://App.Example.com", want: true},
+		{name: "exact origin, trailing slash", allowed: "https://app.example.com/", origin: "https://app.example.com", want: true},
+		{name: "other origin", allowed: "https://app.example.com", origin: "https://app.example.com.evil.net", want: false},
+		{name: "wildcard subdomain", allowed: "https://*.example.com", origin: "https://admin.example.com", want: true},
+		{name: "wildcard, other domain", allowed: "https://*.example.com", origin: "https://example.com.evil.net", want: false},
+		{name: "wildcard, path ending with the domain", allowed: "https://*.example.com", origin: "https://evil.com/.example.com", want: false},
+		{name: "wildcard, nested subdomain", allowed: "https://*.example.com", origin: "https://a.b.example.com", want: false},
+		{name: "wildcard port", allowed: "http://localhost:*", origin: "http://localhost:3000", want: true},
+		{name: "wildcard port, other host", allowed: "http://localhost:*", origin: "http://localhost:3000.evil.net", want: false},
+		{name: "regexp", allowed: `/https://admin-[a-z]+\.example\.com/`, origin: "https://admin-eu.example.com", want: true},
+		{name: "regexp, longer origin", allowed: `/https://admin-[a-z]+\.example\.com/`, origin: "https://admin-eu.example.com.evil.net", want: false},
+		{name: "regexp, origin ending with it", allowed: `/https://admin-[a-z]+\.example\.com/`, origin: "https://evil.net/https://admin-eu.example.com", want: false},
+		{name: "regexp, already anchored", allowed: `/^https://admin-[a-z]+\.example\.com$/`, origin: "https://admin-eu.example.com", want: true},
+		{name: "regexp alternation", allowed: `/https://a\.example\.com|https://b\.example\.com/`, origin: "https://b.example.com.evil.net", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewCORSPolicy(CORSConfig{AllowedOrigins: []string{tt.allowed}})
+			if err != nil {
+				t.Fatalf("NewCORSPolicy: %v", err)
+			}
+			if got := policy.allows(tt.origin); got != tt.want {
+				t.Errorf("allows(%q) with %q = %v, want %v", tt.origin, tt.allowed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCORSPolicyRejectsInvalidConfig(t *testing.T) {
+	if _, err := NewCORSPolicy(CORSConfig{AllowedOrigins: []string{"/https://(/"}}); err == nil {
+		t.Error("NewCORSPolicy with an invalid regexp succeeded, want an error")
+	}
+	if _, err := NewCORSPolicy(CORSConfig{AllowCredentials: true}); err == nil {
+		t.Error("NewCORSPolicy with credentials for any origin succeeded, want an error")
+	}
+}
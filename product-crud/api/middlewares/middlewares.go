@@ -9,37 +9,49 @@ import (
 	"github.com/google/uuid"
 )
 
-func LoggingMiddleware(logger *logger.Logger) gin.HandlerFunc {
+// requestIDKey is the gin context key RequestIDMiddleware stores the request ID under
+const requestIDKey = "X-Request-ID"
+
+// userKey is the gin context key SetUser stores the authenticated user under
+const userKey = "user"
+
+// LoggingMiddleware puts a child of base carrying the request ID, trace ID and route in
+// the request context, where services and repositories find it with logger.FromContext,
+// and logs the request once it is served. It must run after RequestIDMiddleware. The
+// values of sensitive query parameters are redacted from the path logged.
+func LoggingMiddleware(base *logger.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 
+		fields := []logger.Field{
+			logger.String("request_id", c.GetString(requestIDKey)),
+			logger.String("route", c.FullPath()),
+		}
+		if traceID := logger.TraceID(c.GetHeader("traceparent")); traceID != "" {
+			fields = append(fields, logger.String("trace_id", traceID))
+		}
+		c.Request = c.Request.WithContext(logger.NewContext(c.Request.Context(), base.With(fields...)))
+
 		c.Next()
 
-		duration := time.Since(start)
-		logger.Info("HTTP Request",
-			"method", c.Request.Method,
-			"path", c.Request.URL.Path,
-			"status", c.Writer.Status(),
-			"duration", duration,
-			"user_agent", c.Request.UserAgent(),
-			"remote_addr", c.ClientIP(),
-			"request_id", c.Writer.Header().Get("X-Request-ID"))
+		// The request context is read again to pick up the user, if SetUser ran meanwhile
+		logger.FromContext(c.Request.Context()).Info("HTTP Request",
+			logger.String("method", c.Request.Method),
+			logger.String("path", c.Request.URL.Path),
+			logger.String("query", base.Redactor().Query(c.Request.URL.RawQuery)),
+			logger.Int("status", c.Writer.Status()),
+			logger.Duration("duration", time.Since(start)),
+			logger.String("user_agent", c.Request.UserAgent()),
+			logger.String("remote_addr", c.ClientIP()))
 	}
 }
 
-func CORSMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if c.Request.Method == http.MethodOptions {
-			c.AbortWithStatus(http.StatusNoContent)
-			return
-		}
+// SetUser records the user a request is authenticated as, adding it to the request's logger
+func SetUser(c *gin.Context, user string) {
+	c.Set(userKey, user)
 
-		c.Next()
-	}
+	ctx := c.Request.Context()
+	c.Request = c.Request.WithContext(logger.NewContext(ctx, logger.FromContext(ctx).With(logger.String("user", user))))
 }
 
 func RequestIDMiddleware() gin.HandlerFunc {
@@ -51,20 +63,20 @@ func RequestIDMiddleware() gin.HandlerFunc {
 
 		c.Writer.Header().Set("X-Request-ID", requestID)
 
-		c.Set("X-Request-ID", requestID)
+		c.Set(requestIDKey, requestID)
 
 		c.Next()
 	}
 }
 
-func RecoveryMiddleware(logger *logger.Logger) gin.HandlerFunc {
+func RecoveryMiddleware(base *logger.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
-				logger.Error("Recover from panic",
-					"error", err,
-					"path", c.Request.URL.Path,
-					"method", c.Request.Method,
+				base.Ctx(c.Request.Context()).Error("Recover from panic",
+					logger.Any("error", err),
+					logger.String("path", c.Request.URL.Path),
+					logger.String("method", c.Request.Method),
 				)
 
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
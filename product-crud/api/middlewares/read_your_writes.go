@@ -0,0 +1,66 @@
+package middlewares
+
+import (
+	"math"
+	"net/http"
+	"product-crud/pkg/db"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// primaryUntilCookie and primaryUntilHeader carry when a client's read-your-writes
+	// window ends, in Unix milliseconds
+	primaryUntilCookie = "primary_until"
+	primaryUntilHeader = "X-Primary-Until"
+)
+
+// ReadYourWritesMiddleware sends a client's reads to the primary database for window
+// after each write it makes, so they see the write even while the replicas lag. The end
+// of the window is returned in a cookie, for browsers, and in the X-Primary-Until header,
+// which other clients echo back on their next requests.
+func ReadYourWritesMiddleware(window time.Duration) gin.HandlerFunc {
+	maxAge := int(math.Ceil(window.Seconds()))
+
+	return func(c *gin.Context) {
+		now := time.Now()
+
+		// A deadline further away than one window was not issued here
+		if until, ok := primaryUntil(c); ok && until.After(now) && !until.After(now.Add(window)) {
+			c.Request = c.Request.WithContext(db.WithPrimary(c.Request.Context()))
+		}
+
+		if isWrite(c.Request.Method) {
+			// Set before the handler runs, as headers cannot change once it writes the body
+			value := strconv.FormatInt(now.Add(window).UnixMilli(), 10)
+			c.Header(primaryUntilHeader, value)
+			c.SetSameSite(http.SameSiteLaxMode)
+			c.SetCookie(primaryUntilCookie, value, maxAge, "/", "", false, true)
+		}
+
+		c.Next()
+	}
+}
+
+func primaryUntil(c *gin.Context) (time.Time, bool) {
+	value := c.GetHeader(primaryUntilHeader)
+	if value == "" {
+		value, _ = c.Cookie(primaryUntilCookie)
+	}
+
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms), true
+}
+
+func isWrite(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
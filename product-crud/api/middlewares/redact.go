@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"bytes"
+	"mime"
+	"product-crud/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RedactErrorsMiddleware redacts sensitive values from error responses. Handlers often
+// return the message of the error they got, which may quote the input that caused it.
+func RedactErrorsMiddleware(redactor *logger.Redactor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := &redactingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		// On panic the buffered body is dropped, RecoveryMiddleware answers instead
+		defer func() { c.Writer = w.ResponseWriter }()
+
+		c.Next()
+
+		if !w.buffered {
+			return
+		}
+		body := w.body.Bytes()
+		if isJSON(w.Header().Get("Content-Type")) {
+			body = redactor.JSON(body)
+		} else {
+			body = []byte(redactor.String(string(body)))
+		}
+		w.Header().Del("Content-Length")
+		_, _ = w.ResponseWriter.Write(body)
+	}
+}
+
+// redactingWriter holds back the body of error responses until the handler is done, as
+// a JSON document can only be redacted whole
+type redactingWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	buffered bool
+}
+
+func (w *redactingWriter) Write(data []byte) (int, error) {
+	if w.Status() < 400 {
+		return w.ResponseWriter.Write(data)
+	}
+	w.buffered = true
+	return w.body.Write(data)
+}
+
+func (w *redactingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || mediaType == "application/problem+json"
+}
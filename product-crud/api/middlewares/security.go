@@ -0,0 +1,177 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// swaggerPath prefixes the Swagger UI and playgroundPath serves the GraphiQL
+	// playground on GET, the only HTML the API serves
+	swaggerPath    = "/swagger"
+	playgroundPath = "/graphql"
+
+	// cspNonceKey is the gin context key the nonce of the playground's policy is stored under
+	cspNonceKey = "csp_nonce"
+	// cspNoncePlaceholder is replaced by that nonce in PlaygroundContentSecurityPolicy
+	cspNoncePlaceholder = "{nonce}"
+)
+
+// SecurityHeadersConfig sets the headers SecurityHeadersMiddleware adds to every response
+type SecurityHeadersConfig struct {
+	// HSTSMaxAge is how long browsers must only use HTTPS for this host; zero sends no
+	// Strict-Transport-Security header
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	// ContentSecurityPolicy applies to the API. SwaggerContentSecurityPolicy applies to
+	// the Swagger UI, which needs inline scripts and styles. PlaygroundContentSecurityPolicy
+	// applies to the GraphiQL playground, which loads its assets from unpkg; every
+	// {nonce} in it is replaced by a nonce fresh for each page, see CSPNonce.
+	ContentSecurityPolicy           string
+	SwaggerContentSecurityPolicy    string
+	PlaygroundContentSecurityPolicy string
+	// FrameAncestors are the sources allowed to embed responses in a frame, added to
+	// every policy; empty allows none
+	FrameAncestors []string
+	ReferrerPolicy string
+}
+
+// Defaults of a SecurityHeadersConfig leaving the corresponding setting empty
+const (
+	DefaultContentSecurityPolicy           = "default-src 'none'"
+	DefaultSwaggerContentSecurityPolicy    = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:"
+	DefaultPlaygroundContentSecurityPolicy = "default-src 'none'; script-src 'nonce-{nonce}' https://unpkg.com; style-src 'nonce-{nonce}' https://unpkg.com; " +
+		"font-src https://unpkg.com data:; img-src 'self' data:; connect-src 'self'"
+	DefaultReferrerPolicy = "no-referrer"
+)
+
+// SecurityHeadersMiddleware sets HSTS, the content security policy, including
+// frame-ancestors, and the X-Content-Type-Options and Referrer-Policy headers
+func SecurityHeadersMiddleware(cfg SecurityHeadersConfig) gin.HandlerFunc {
+	frameAncestors := "frame-ancestors 'none'"
+	if len(cfg.FrameAncestors) > 0 {
+		frameAncestors = "frame-ancestors " + strings.Join(cfg.FrameAncestors, " ")
+	}
+	apiPolicy := orDefaultString(cfg.ContentSecurityPolicy, DefaultContentSecurityPolicy) + "; " + frameAncestors
+	swaggerPolicy := orDefaultString(cfg.SwaggerContentSecurityPolicy, DefaultSwaggerContentSecurityPolicy) + "; " + frameAncestors
+	playgroundPolicy := orDefaultString(cfg.PlaygroundContentSecurityPolicy, DefaultPlaygroundContentSecurityPolicy) + "; " + frameAncestors
+	referrerPolicy := orDefaultString(cfg.ReferrerPolicy, DefaultReferrerPolicy)
+
+	var hsts string
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(cfg.HSTSMaxAge.Seconds()))
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		if hsts != "" {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+		switch path := c.Request.URL.Path; {
+		case path == swaggerPath || strings.HasPrefix(path, swaggerPath+"/"):
+			header.Set("Content-Security-Policy", swaggerPolicy)
+		case path == playgroundPath && c.Request.Method == http.MethodGet:
+			nonce := newNonce()
+			c.Set(cspNonceKey, nonce)
+			header.Set("Content-Security-Policy", strings.ReplaceAll(playgroundPolicy, cspNoncePlaceholder, nonce))
+		default:
+			header.Set("Content-Security-Policy", apiPolicy)
+		}
+		// For browsers predating frame-ancestors
+		if len(cfg.FrameAncestors) == 0 {
+			header.Set("X-Frame-Options", "DENY")
+		}
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("Referrer-Policy", referrerPolicy)
+
+		c.Next()
+	}
+}
+
+// CSPNonce returns the nonce the GraphiQL playground's inline scripts and styles must
+// carry to be allowed, or "" for any other page
+func CSPNonce(c *gin.Context) string {
+	return c.GetString(cspNonceKey)
+}
+
+func newNonce() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func orDefaultString(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// JSONBodyMiddleware guards endpoints taking JSON: bodies must be declared as JSON, or
+// the request is refused with 415, and may not exceed maxBytes, or it is refused with 413.
+// Requests without a body pass, as do GET, HEAD and OPTIONS requests.
+func JSONBodyMiddleware(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength == 0 && len(c.Request.TransferEncoding) == 0 {
+			c.Next()
+			return
+		}
+
+		if !isJSONContentType(c.ContentType()) {
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be application/json"})
+			return
+		}
+
+		if maxBytes > 0 {
+			if c.Request.ContentLength > maxBytes {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Request body exceeds %d bytes", maxBytes)})
+				return
+			}
+			// Bodies of unknown length are read up to the limit here, since a handler would
+			// only see a decoding error once they exceed it
+			if c.Request.ContentLength < 0 {
+				body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBytes+1))
+				if err != nil {
+					c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+					return
+				}
+				if int64(len(body)) > maxBytes {
+					c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Request body exceeds %d bytes", maxBytes)})
+					return
+				}
+				c.Request.Body = io.NopCloser(bytes.NewReader(body))
+				c.Request.ContentLength = int64(len(body))
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// isJSONContentType accepts application/json and the JSON based types, such as
+// application/merge-patch+json
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || (strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
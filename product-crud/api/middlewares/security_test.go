@@ -0,0 +1,73 @@
+package middlewares
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveJSON runs req through JSONBodyMiddleware in front of a handler echoing the body
+func serveJSON(maxBytes int64, req *http.Request) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(JSONBodyMiddleware(maxBytes))
+	router.POST("/", func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.Data(http.StatusOK, "application/json", body)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestJSONBodyMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		// chunked hides the body's length, as Transfer-Encoding: chunked does
+		chunked bool
+		want    int
+	}{
+		{name: "within the limit", contentType: "application/json", body: `{"a":1}`, want: http.StatusOK},
+		{name: "JSON based type", contentType: "application/merge-patch+json", body: `{"a":1}`, want: http.StatusOK},
+		{name: "not JSON", contentType: "text/plain", body: `{"a":1}`, want: http.StatusUnsupportedMediaType},
+		{name: "declared too large", contentType: "application/json", body: `{"a":"` + strings.Repeat("x", 16) + `"}`, want: http.StatusRequestEntityTooLarge},
+		{name: "chunked within the limit", contentType: "application/json", body: `{"a":1}`, chunked: true, want: http.StatusOK},
+		{name: "chunked at the limit", contentType: "application/json", body: `{"a":"` + strings.Repeat("x", 8) + `"}`, chunked: true, want: http.StatusOK},
+		{name: "chunked too large", contentType: "application/json", body: `{"a":"` + strings.Repeat("x", 9) + `"}`, chunked: true, want: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader = strings.NewReader(tt.body)
+			if tt.chunked {
+				// Not a *strings.Reader, so the request cannot know its length
+				body = io.MultiReader(body)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/", body)
+			if tt.chunked {
+				req.ContentLength = -1
+				req.TransferEncoding = []string{"chunked"}
+			}
+			req.Header.Set("Content-Type", tt.contentType)
+
+			w := serveJSON(16, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+			if tt.want == http.StatusOK && w.Body.String() != tt.body {
+				t.Errorf("handler read %q, want %q", w.Body.String(), tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,4 @@
+// Package proto holds the protobuf definitions of the gRPC API and the code generated from them.
+package proto
+
+//go:generate protoc -I . --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative product/v1/product.proto
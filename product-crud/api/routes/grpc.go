@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"product-crud/api/interceptors"
+	productv1 "product-crud/api/proto/product/v1"
+	grpcdelivery "product-crud/internal/delivery/grpc"
+	"product-crud/pkg/logger"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// SetupGRPCServer registers the gRPC services behind the same cross-cutting concerns as the
+// gin router: request IDs, logging, panic recovery and, when tokens are given, bearer auth
+func SetupGRPCServer(productServer *grpcdelivery.ProductServer, logger *logger.Logger, authTokens []string) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			interceptors.UnaryRequestID(),
+			interceptors.UnaryLogging(logger),
+			interceptors.UnaryRecovery(logger),
+			interceptors.UnaryAuth(authTokens),
+		),
+		grpc.ChainStreamInterceptor(
+			interceptors.StreamRequestID(),
+			interceptors.StreamLogging(logger),
+			interceptors.StreamRecovery(logger),
+			interceptors.StreamAuth(authTokens),
+		),
+	)
+
+	productv1.RegisterProductServiceServer(server, productServer)
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	healthServer.SetServingStatus(productv1.ProductService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	reflection.Register(server)
+
+	return server
+}
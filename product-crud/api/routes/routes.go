@@ -1,40 +1,88 @@
 package routes
 
 import (
-	"net/http"
 	"product-crud/api/middlewares"
+	"product-crud/internal/delivery/graphql"
 	"product-crud/internal/delivery/rest"
 	"product-crud/pkg/logger"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetupRouter(productHandler *rest.ProductHandler) *gin.Engine {
-	router := gin.Default()
+// Security configures the protections SetupRouter puts in front of the handlers
+type Security struct {
+	Headers middlewares.SecurityHeadersConfig
+	// MaxBodyBytes bounds JSON request bodies; zero leaves them unbounded. Image uploads
+	// have their own limit.
+	MaxBodyBytes int64
+	// TrustedProxies are the IPs and CIDRs whose X-Forwarded-For and X-Real-IP headers
+	// name the client; for requests from anywhere else the client is the peer address.
+	// Empty trusts no proxy.
+	TrustedProxies []string
+	// TrustedPlatform is a header the hosting platform sets to the client IP, such as
+	// CF-Connecting-IP, believed whatever the peer; only set it when the platform strips
+	// the header from incoming requests
+	TrustedPlatform string
+	// Admin restricts the admin routes to some client IPs
+	Admin *middlewares.IPFilter
+}
+
+// SetupRouter builds the HTTP API. A non-zero readYourWrites keeps each client's reads on
+// the primary database for that long after it writes; cors decides which browser origins
+// may call each route group.
+func SetupRouter(log *logger.Logger, readYourWrites time.Duration, cors middlewares.CORSRules, security Security, productHandler *rest.ProductHandler, categoryHandler *rest.CategoryHandler, inventoryHandler *rest.InventoryHandler, variantHandler *rest.VariantHandler, imageHandler *rest.ImageHandler, priceHandler *rest.PriceHandler, cacheHandler *rest.CacheHandler, logHandler *rest.LogHandler, healthHandler *rest.HealthHandler, graphqlHandler *graphql.Handler) (*gin.Engine, error) {
+	// gin's own output, such as route registration in debug mode, goes through our logger;
+	// requests are logged and panics recovered by our middlewares instead of gin's
+	gin.DefaultWriter = log.Writer(logger.DebugLevel)
+	gin.DefaultErrorWriter = log.Writer(logger.ErrorLevel)
+	router := gin.New()
 
-	logger := logger.NewLogger("info")
+	// gin trusts every proxy by default, letting any client choose its IP
+	if err := router.SetTrustedProxies(security.TrustedProxies); err != nil {
+		return nil, err
+	}
+	router.TrustedPlatform = security.TrustedPlatform
 
-	router.Use(middlewares.CORSMiddleware())
-	router.Use(middlewares.LoggingMiddleware(logger))
+	router.Use(middlewares.SecurityHeadersMiddleware(security.Headers))
+	router.Use(middlewares.CORSMiddleware(cors))
 	router.Use(middlewares.RequestIDMiddleware())
-	router.Use(middlewares.RecoveryMiddleware(logger))
+	router.Use(middlewares.LoggingMiddleware(log))
+	router.Use(middlewares.RecoveryMiddleware(log))
+	router.Use(middlewares.RedactErrorsMiddleware(log.Redactor()))
 
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status": "OK",
-		})
-	})
+	router.GET("/health", healthHandler.Health)
 
-	v1 := router.Group("/api/v1")
+	jsonBody := middlewares.JSONBodyMiddleware(security.MaxBodyBytes)
+
+	api := router.Group("/api/v1")
+	if readYourWrites > 0 {
+		api.Use(middlewares.ReadYourWritesMiddleware(readYourWrites))
+	}
+	// Every endpoint takes JSON, except image uploads
+	v1 := api.Group("", jsonBody)
 	{
 		setupProductRoutes(v1, productHandler)
+		setupCategoryRoutes(v1, categoryHandler)
+		setupInventoryRoutes(v1, inventoryHandler)
+		setupVariantRoutes(v1, variantHandler)
+		setupImageRoutes(v1, api, imageHandler)
+		setupPriceRoutes(v1, priceHandler)
+		setupAdminRoutes(v1, security.Admin, imageHandler, cacheHandler, logHandler)
+	}
+
+	router.GET("/media/*key", imageHandler.ServeMedia)
+
+	router.POST("/graphql", jsonBody, graphqlHandler.Query)
+	if graphqlHandler.PlaygroundEnabled() {
+		router.GET("/graphql", graphqlHandler.Playground)
 	}
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
-	return router
+	return router, nil
 }
 
 func setupProductRoutes(rg *gin.RouterGroup, handler *rest.ProductHandler) {
@@ -47,3 +95,84 @@ func setupProductRoutes(rg *gin.RouterGroup, handler *rest.ProductHandler) {
 		products.DELETE("/:id", handler.DeleteProduct)
 	}
 }
+
+func setupCategoryRoutes(rg *gin.RouterGroup, handler *rest.CategoryHandler) {
+	categories := rg.Group("/categories")
+	{
+		categories.POST("", handler.CreateCategory)
+		categories.GET("", handler.GetCategories)
+		categories.GET("/:id", handler.GetCategory)
+		categories.GET("/:id/products", handler.GetCategoryProducts)
+		categories.PUT("/:id", handler.UpdateCategory)
+		categories.DELETE("/:id", handler.DeleteCategory)
+	}
+}
+
+func setupInventoryRoutes(rg *gin.RouterGroup, handler *rest.InventoryHandler) {
+	products := rg.Group("/products")
+	{
+		products.GET("/:id/stock", handler.GetStock)
+		products.POST("/:id/stock/adjustments", handler.AdjustStock)
+		products.GET("/:id/stock/ledger", handler.GetStockLedger)
+		products.POST("/:id/reservations", handler.CreateReservation)
+	}
+
+	reservations := rg.Group("/reservations")
+	{
+		reservations.GET("/:id", handler.GetReservation)
+		reservations.POST("/:id/commit", handler.CommitReservation)
+		reservations.DELETE("/:id", handler.ReleaseReservation)
+	}
+}
+
+func setupVariantRoutes(rg *gin.RouterGroup, handler *rest.VariantHandler) {
+	products := rg.Group("/products")
+	{
+		products.GET("/sku/:sku", handler.GetProductBySKU)
+		products.POST("/:id/variants", handler.CreateVariant)
+		products.GET("/:id/variants", handler.GetVariants)
+		products.GET("/:id/variants/:variantId", handler.GetVariant)
+		products.PUT("/:id/variants/:variantId", handler.UpdateVariant)
+		products.DELETE("/:id/variants/:variantId", handler.DeleteVariant)
+	}
+}
+
+// setupImageRoutes registers uploads on uploads, as they take multipart bodies
+func setupImageRoutes(rg, uploads *gin.RouterGroup, handler *rest.ImageHandler) {
+	uploads.POST("/products/:id/images", handler.UploadImage)
+
+	products := rg.Group("/products")
+	{
+		products.GET("/:id/images", handler.GetImages)
+		products.PUT("/:id/images/:imageId", handler.UpdateImage)
+		products.DELETE("/:id/images/:imageId", handler.DeleteImage)
+	}
+}
+
+func setupPriceRoutes(rg *gin.RouterGroup, handler *rest.PriceHandler) {
+	products := rg.Group("/products")
+	{
+		products.POST("/:id/prices", handler.SchedulePrice)
+		products.GET("/:id/prices", handler.GetPriceHistory)
+		products.DELETE("/:id/prices/:priceId", handler.CancelPrice)
+		products.GET("/:id/price", handler.GetPriceAt)
+	}
+}
+
+func setupAdminRoutes(rg *gin.RouterGroup, filter *middlewares.IPFilter, imageHandler *rest.ImageHandler, cacheHandler *rest.CacheHandler, logHandler *rest.LogHandler) {
+	admin := rg.Group("/admin")
+	if filter != nil {
+		admin.Use(middlewares.IPFilterMiddleware(filter))
+	}
+	{
+		admin.POST("/images/regenerate", imageHandler.RegenerateImages)
+
+		admin.GET("/cache/stats", cacheHandler.GetCacheStats)
+		admin.GET("/cache/keys", cacheHandler.InspectCacheKey)
+		admin.DELETE("/cache", cacheHandler.FlushCache)
+		admin.POST("/cache/warmup", cacheHandler.WarmUpCache)
+
+		admin.GET("/log-level", logHandler.GetLogLevel)
+		admin.PUT("/log-level", logHandler.SetLogLevel)
+	}
+}
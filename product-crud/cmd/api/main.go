@@ -1,20 +1,35 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"product-crud/api/middlewares"
 	"product-crud/api/routes"
 	_ "product-crud/docs"
+	"product-crud/internal/delivery/graphql"
+	grpcdelivery "product-crud/internal/delivery/grpc"
 	"product-crud/internal/delivery/rest"
 	"product-crud/internal/repository"
 	"product-crud/internal/service"
 	"product-crud/pkg/cache"
 	"product-crud/pkg/db"
+	"product-crud/pkg/imaging"
 	"product-crud/pkg/logger"
+	"product-crud/pkg/storage"
 
 	"github.com/joho/godotenv"
+	"google.golang.org/grpc"
 )
 
 // @title Go Gin CRUD API
@@ -27,41 +42,416 @@ func main() {
 		log.Println("Warning: .env file not found")
 	}
 
-	database, err := db.Connect()
+	appLogger, err := newLogger()
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer appLogger.Close()
+	reloadOnSIGHUP(appLogger)
+
+	dbMaxOpenConns, _ := strconv.Atoi(getEnv("DB_MAX_OPEN_CONNS", "25"))
+	dbMaxIdleConns, _ := strconv.Atoi(getEnv("DB_MAX_IDLE_CONNS", "10"))
+	dbConnMaxLifetime, _ := strconv.Atoi(getEnv("DB_CONN_MAX_LIFETIME", "1800"))
+	dbConnMaxIdleTime, _ := strconv.Atoi(getEnv("DB_CONN_MAX_IDLE_TIME", "300"))
+	dbConnectTimeout, _ := strconv.Atoi(getEnv("DB_CONNECT_TIMEOUT", "60"))
+	dbSlowQueryThreshold, _ := strconv.Atoi(getEnv("DB_SLOW_QUERY_THRESHOLD_MS", "200"))
+
+	cluster, err := db.Connect(db.Config{
+		DSN:                getEnv("DATABASE_URL", ""),
+		Host:               getEnv("DB_HOST", ""),
+		Port:               getEnv("DB_PORT", ""),
+		User:               getEnv("DB_USER", ""),
+		Password:           getEnv("DB_PASSWORD", ""),
+		Name:               getEnv("DB_NAME", ""),
+		SSLMode:            getEnv("DB_SSLMODE", ""),
+		SSLRootCert:        getEnv("DB_SSLROOTCERT", ""),
+		SSLCert:            getEnv("DB_SSLCERT", ""),
+		SSLKey:             getEnv("DB_SSLKEY", ""),
+		ReplicaDSNs:        splitList(getEnv("DB_REPLICA_DSNS", "")),
+		MaxOpenConns:       dbMaxOpenConns,
+		MaxIdleConns:       dbMaxIdleConns,
+		ConnMaxLifetime:    time.Duration(dbConnMaxLifetime) * time.Second,
+		ConnMaxIdleTime:    time.Duration(dbConnMaxIdleTime) * time.Second,
+		ConnectTimeout:     time.Duration(dbConnectTimeout) * time.Second,
+		Logger:             appLogger,
+		SlowQueryThreshold: time.Duration(dbSlowQueryThreshold) * time.Millisecond,
+	})
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
+	database := cluster.Primary()
+
+	cluster.StartHealthChecks(context.Background(), getEnvInterval("DB_REPLICA_HEALTH_INTERVAL", 5))
 
-	if err := db.InitSchema(database); err != nil {
+	if err := db.InitSchema(database, appLogger); err != nil {
 		log.Fatalf("Failed to initialize database schema: %v", err)
 	}
 
-	logger := logger.NewLogger("info")
-
 	// Initialize Redis cache
-	redisHost := getEnv("REDIS_HOST", "localhost")
-	redisPort := getEnv("REDIS_PORT", "6379")
-	redisPassword := getEnv("REDIS_PASSWORD", "")
+	// REDIS_URL, or REDIS_ADDRS (the server, sentinels or cluster nodes), replaces REDIS_HOST and REDIS_PORT
+	redisURL := getEnv("REDIS_URL", "")
+	redisAddrs := splitList(getEnv("REDIS_ADDRS", ""))
+	if redisURL == "" && len(redisAddrs) == 0 {
+		redisAddrs = []string{getEnv("REDIS_HOST", "localhost") + ":" + getEnv("REDIS_PORT", "6379")}
+	}
+	redisDB, _ := strconv.Atoi(getEnv("REDIS_DB", "0"))
+	redisTLS, _ := strconv.ParseBool(getEnv("REDIS_TLS", "false"))
+	redisPoolSize, _ := strconv.Atoi(getEnv("REDIS_POOL_SIZE", "0"))
+	redisMinIdleConns, _ := strconv.Atoi(getEnv("REDIS_MIN_IDLE_CONNS", "0"))
 	cacheTTL, _ := strconv.Atoi(getEnv("CACHE_TTL", "3600"))
+	cacheStaleTTL, _ := strconv.Atoi(getEnv("CACHE_STALE_TTL", "60"))
+	negativeCacheTTL, _ := strconv.Atoi(getEnv("NEGATIVE_CACHE_TTL", "30"))
+	localCacheSize, _ := strconv.ParseInt(getEnv("LOCAL_CACHE_MAX_BYTES", "67108864"), 10, 64)
+	localCacheTTL, _ := strconv.Atoi(getEnv("LOCAL_CACHE_TTL", "30"))
+	redisTimeout, _ := strconv.Atoi(getEnv("REDIS_TIMEOUT_MS", "250"))
+	redisBreakerFailures, _ := strconv.ParseUint(getEnv("REDIS_BREAKER_FAILURES", "5"), 10, 32)
+	redisBreakerOpenTimeout, _ := strconv.Atoi(getEnv("REDIS_BREAKER_OPEN_TIMEOUT", "10"))
+	redisBreakerProbes, _ := strconv.ParseUint(getEnv("REDIS_BREAKER_PROBES", "3"), 10, 32)
+
+	var localCache *cache.LocalCache
+	if localCacheSize > 0 && localCacheTTL > 0 {
+		localCache = cache.NewLocalCache(localCacheSize, time.Duration(localCacheTTL)*time.Second)
+	}
+
+	redisCache, err := cache.NewRedisCache(cache.Config{
+		URL:              redisURL,
+		Mode:             getEnv("REDIS_MODE", cache.ModeStandalone),
+		Addrs:            redisAddrs,
+		MasterName:       getEnv("REDIS_MASTER_NAME", ""),
+		Username:         getEnv("REDIS_USERNAME", ""),
+		Password:         getEnv("REDIS_PASSWORD", ""),
+		SentinelPassword: getEnv("REDIS_SENTINEL_PASSWORD", ""),
+		DB:               redisDB,
+		TLS: cache.TLSConfig{
+			Enabled:    redisTLS,
+			CACert:     getEnv("REDIS_TLS_CA_CERT", ""),
+			Cert:       getEnv("REDIS_TLS_CERT", ""),
+			Key:        getEnv("REDIS_TLS_KEY", ""),
+			ServerName: getEnv("REDIS_TLS_SERVER_NAME", ""),
+		},
+		PoolSize:     redisPoolSize,
+		MinIdleConns: redisMinIdleConns,
+		KeyPrefix:    getEnv("REDIS_KEY_PREFIX", ""),
+
+		TTL:         time.Duration(cacheTTL) * time.Second,
+		StaleTTL:    time.Duration(cacheStaleTTL) * time.Second,
+		NegativeTTL: time.Duration(negativeCacheTTL) * time.Second,
+		Local:       localCache,
 
-	redisCache, err := cache.NewRedisCache(redisHost+":"+redisPort, redisPassword, cacheTTL)
+		OperationTimeout: time.Duration(redisTimeout) * time.Millisecond,
+		Breaker: cache.BreakerConfig{
+			Failures:    uint32(redisBreakerFailures),
+			OpenTimeout: time.Duration(redisBreakerOpenTimeout) * time.Second,
+			Probes:      uint32(redisBreakerProbes),
+		},
+
+		Logger: appLogger,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize Redis cache: %v", err)
+	}
+	redisCache.StartInvalidationListener(context.Background())
+	redisCache.StartHealthCheck(context.Background(), getEnvInterval("REDIS_HEALTH_INTERVAL", 2))
+
+	productRepo := repository.NewProductRepository(cluster)
+	categoryRepo := repository.NewCategoryRepository(database)
+	inventoryRepo := repository.NewInventoryRepository(database)
+	variantRepo := repository.NewVariantRepository(database)
+	imageRepo := repository.NewImageRepository(database)
+	priceRepo := repository.NewPriceRepository(database)
+
+	s3UseSSL, _ := strconv.ParseBool(getEnv("S3_USE_SSL", "true"))
+	blob, err := storage.New(storage.Config{
+		Driver:      getEnv("STORAGE_DRIVER", "local"),
+		LocalDir:    getEnv("STORAGE_LOCAL_DIR", "./data/media"),
+		S3Endpoint:  getEnv("S3_ENDPOINT", ""),
+		S3Region:    getEnv("S3_REGION", ""),
+		S3Bucket:    getEnv("S3_BUCKET", ""),
+		S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+		S3UseSSL:    s3UseSSL,
+	})
 	if err != nil {
-		log.Printf("Warning: Failed to connect to Redis: %v", err)
-		log.Println("Continuing without cache...")
+		log.Fatalf("Failed to initialize media storage: %v", err)
+	}
+	maxImageSize, _ := strconv.ParseInt(getEnv("MAX_IMAGE_SIZE", "10485760"), 10, 64)
+	imagePresets, err := imaging.ParsePresets(getEnv("IMAGE_PRESETS", imaging.DefaultPresets))
+	if err != nil {
+		log.Fatalf("Invalid IMAGE_PRESETS: %v", err)
+	}
+	imageWorkers, _ := strconv.Atoi(getEnv("IMAGE_WORKERS", "2"))
+
+	reservationTTL, _ := strconv.Atoi(getEnv("RESERVATION_TTL", "900"))
+
+	productIDFilter, _ := strconv.ParseBool(getEnv("PRODUCT_ID_FILTER", "false"))
+	productIDFilterCapacity := 0
+	if productIDFilter {
+		productIDFilterCapacity, _ = strconv.Atoi(getEnv("PRODUCT_ID_FILTER_CAPACITY", "1000000"))
+	}
+
+	// Cancelled by SIGINT or SIGTERM, which stops the background workers and then the servers
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	productService := service.NewProductService(productRepo, categoryRepo, redisCache, appLogger, productIDFilterCapacity)
+	if err := productService.RebuildIDFilter(ctx); err != nil {
+		log.Printf("Warning: Failed to build product ID filter: %v", err)
+	}
+	productService.StartIDFilterRebuild(ctx, getEnvInterval("PRODUCT_ID_FILTER_REBUILD_INTERVAL", 3600))
+	productService.StartPopularityTracking(ctx, getEnvInterval("POPULARITY_FLUSH_INTERVAL", 10))
+	categoryService := service.NewCategoryService(categoryRepo, redisCache, appLogger)
+	inventoryService := service.NewInventoryService(inventoryRepo, productRepo, appLogger, time.Duration(reservationTTL)*time.Second)
+	inventoryService.StartReservationSweeper(ctx, getEnvInterval("RESERVATION_SWEEP_INTERVAL", 60))
+	variantService := service.NewVariantService(variantRepo, productRepo, productService, appLogger)
+	imageService := service.NewImageService(imageRepo, productRepo, productService, blob, appLogger, maxImageSize, imagePresets)
+	imageService.StartVariantWorker(ctx, imageWorkers, getEnvInterval("IMAGE_WORKER_INTERVAL", 30))
+	priceService := service.NewPriceService(priceRepo, productRepo, productService, appLogger)
+	priceService.StartPriceScheduler(ctx, getEnvInterval("PRICE_SCHEDULER_INTERVAL", 15))
+
+	cacheService := service.NewCacheService(redisCache, productService, appLogger)
+
+	// Preload the products most requested before the restart
+	if warmUpCount, _ := strconv.Atoi(getEnv("CACHE_WARMUP_COUNT", "0")); warmUpCount > 0 {
+		if _, err := cacheService.WarmUp(ctx, warmUpCount); err != nil {
+			log.Printf("Warning: Failed to warm up cache: %v", err)
+		}
 	}
 
-	productRepo := repository.NewProductRepository(database)
-	productService := service.NewProductService(productRepo, redisCache, logger)
 	productHandler := rest.NewProductHandler(productService)
+	categoryHandler := rest.NewCategoryHandler(categoryService, productService)
+	inventoryHandler := rest.NewInventoryHandler(inventoryService)
+	variantHandler := rest.NewVariantHandler(variantService)
+	imageHandler := rest.NewImageHandler(imageService)
+	priceHandler := rest.NewPriceHandler(priceService)
+	cacheHandler := rest.NewCacheHandler(cacheService)
+	healthHandler := rest.NewHealthHandler(cacheService)
+	logHandler := rest.NewLogHandler(appLogger)
+
+	graphqlPlayground, _ := strconv.ParseBool(getEnv("GRAPHQL_PLAYGROUND", "false"))
+	graphqlMaxDepth, _ := strconv.Atoi(getEnv("GRAPHQL_MAX_DEPTH", "15"))
+	graphqlMaxComplexity, _ := strconv.Atoi(getEnv("GRAPHQL_MAX_COMPLEXITY", "5000"))
+	graphqlHandler, err := graphql.NewHandler(productService, categoryService, graphql.Config{
+		Playground:    graphqlPlayground,
+		MaxDepth:      graphqlMaxDepth,
+		MaxComplexity: graphqlMaxComplexity,
+	})
+	if err != nil {
+		log.Fatalf("Failed to build GraphQL schema: %v", err)
+	}
+
+	readYourWritesWindow, _ := strconv.Atoi(getEnv("READ_YOUR_WRITES_WINDOW", "5"))
+
+	corsRules, err := newCORSRules()
+	if err != nil {
+		log.Fatalf("Invalid CORS configuration: %v", err)
+	}
 
-	router := routes.SetupRouter(productHandler)
+	security, err := newSecurity()
+	if err != nil {
+		log.Fatalf("Invalid security configuration: %v", err)
+	}
 
-	port := getEnv("PORT", "8080")
+	router, err := routes.SetupRouter(appLogger, time.Duration(readYourWritesWindow)*time.Second, corsRules, security, productHandler, categoryHandler, inventoryHandler, variantHandler, imageHandler, priceHandler, cacheHandler, logHandler, healthHandler, graphqlHandler)
+	if err != nil {
+		log.Fatalf("Failed to set up the router: %v", err)
+	}
 
-	log.Printf("Server starting on port %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	grpcPort := getEnv("GRPC_PORT", "9090")
+	grpcAuthTokens := splitList(getEnv("GRPC_AUTH_TOKENS", ""))
+	if len(grpcAuthTokens) == 0 {
+		log.Println("Warning: GRPC_AUTH_TOKENS is empty, the gRPC API accepts unauthenticated calls")
 	}
+
+	grpcServer := routes.SetupGRPCServer(grpcdelivery.NewProductServer(productService), appLogger, grpcAuthTokens)
+	grpcListener, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		log.Fatalf("Failed to listen on gRPC port %s: %v", grpcPort, err)
+	}
+
+	httpServer := &http.Server{
+		Addr:    ":" + getEnv("PORT", "8080"),
+		Handler: router.Handler(),
+	}
+	shutdownTimeout, _ := strconv.Atoi(getEnv("SHUTDOWN_TIMEOUT", "30"))
+
+	if err := serve(ctx, httpServer, grpcServer, grpcListener, time.Duration(shutdownTimeout)*time.Second); err != nil {
+		appLogger.Error("Server failed", logger.Err(err))
+		// os.Exit skips deferred calls
+		stop()
+		appLogger.Close()
+		os.Exit(1)
+	}
+	appLogger.Info("Server stopped")
+}
+
+// serve runs the HTTP and gRPC servers until ctx is cancelled or either of them fails,
+// then stops both, leaving in-flight requests up to shutdownTimeout to complete
+func serve(ctx context.Context, httpServer *http.Server, grpcServer *grpc.Server, grpcListener net.Listener, shutdownTimeout time.Duration) error {
+	errs := make(chan error, 2)
+	go func() {
+		log.Printf("gRPC server starting on %s", grpcListener.Addr())
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			errs <- fmt.Errorf("gRPC server: %w", err)
+		}
+	}()
+	go func() {
+		log.Printf("Server starting on %s", httpServer.Addr)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errs <- fmt.Errorf("HTTP server: %w", err)
+		}
+	}()
+
+	var err error
+	select {
+	case <-ctx.Done():
+		log.Println("Shutting down")
+	case err = <-errs:
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+
+	if shutdownErr := httpServer.Shutdown(shutdownCtx); shutdownErr != nil && err == nil {
+		err = fmt.Errorf("HTTP server shutdown: %w", shutdownErr)
+	}
+
+	select {
+	case <-grpcStopped:
+	case <-shutdownCtx.Done():
+		// Cut the streams still open, such as product subscriptions
+		grpcServer.Stop()
+	}
+
+	return err
+}
+
+// newLogger builds the application logger from the LOG_* environment variables
+func newLogger() (*logger.Logger, error) {
+	maxSize, _ := strconv.Atoi(getEnv("LOG_FILE_MAX_SIZE", "100"))
+	maxAge, _ := strconv.Atoi(getEnv("LOG_FILE_MAX_AGE", "28"))
+	maxBackups, _ := strconv.Atoi(getEnv("LOG_FILE_MAX_BACKUPS", "10"))
+	compress, _ := strconv.ParseBool(getEnv("LOG_FILE_COMPRESS", "true"))
+	syslogEnabled, _ := strconv.ParseBool(getEnv("LOG_SYSLOG", "false"))
+
+	return logger.New(logger.Config{
+		Level:  getEnv("LOG_LEVEL", "info"),
+		Stdout: getEnv("LOG_STDOUT", "console"),
+		File: logger.FileConfig{
+			Path:       getEnv("LOG_FILE", "log/log.txt"),
+			MaxSizeMB:  maxSize,
+			MaxAgeDays: maxAge,
+			MaxBackups: maxBackups,
+			Compress:   compress,
+		},
+		Syslog: logger.SyslogConfig{
+			Enabled: syslogEnabled,
+			Network: getEnv("LOG_SYSLOG_NETWORK", ""),
+			Addr:    getEnv("LOG_SYSLOG_ADDR", ""),
+			Tag:     getEnv("LOG_SYSLOG_TAG", "product-crud"),
+		},
+		// Patterns are separated by semicolons, as regular expressions may contain commas
+		Redaction: logger.RedactionConfig{
+			Fields:   splitList(getEnv("LOG_REDACT_FIELDS", "")),
+			Patterns: splitListOn(getEnv("LOG_REDACT_PATTERNS", ""), ";"),
+		},
+	})
+}
+
+// newCORSRules builds the CORS policies from the CORS_* environment variables. Setting
+// CORS_ADMIN_ALLOWED_ORIGINS gives the admin routes their own allowlist, with credentials
+// unless CORS_ADMIN_ALLOW_CREDENTIALS is false; they share the rest of the settings.
+func newCORSRules() (middlewares.CORSRules, error) {
+	allowCredentials, _ := strconv.ParseBool(getEnv("CORS_ALLOW_CREDENTIALS", "false"))
+	maxAge, _ := strconv.Atoi(getEnv("CORS_MAX_AGE", "600"))
+
+	cfg := middlewares.CORSConfig{
+		AllowedOrigins:   splitList(getEnv("CORS_ALLOWED_ORIGINS", "*")),
+		AllowedMethods:   splitList(getEnv("CORS_ALLOWED_METHODS", "")),
+		AllowedHeaders:   splitList(getEnv("CORS_ALLOWED_HEADERS", "")),
+		ExposedHeaders:   splitList(getEnv("CORS_EXPOSED_HEADERS", "")),
+		AllowCredentials: allowCredentials,
+		MaxAge:           time.Duration(maxAge) * time.Second,
+	}
+	policy, err := middlewares.NewCORSPolicy(cfg)
+	if err != nil {
+		return middlewares.CORSRules{}, err
+	}
+	rules := middlewares.CORSRules{Default: policy, Groups: map[string]*middlewares.CORSPolicy{}}
+
+	if adminOrigins := splitList(getEnv("CORS_ADMIN_ALLOWED_ORIGINS", "")); len(adminOrigins) > 0 {
+		cfg.AllowedOrigins = adminOrigins
+		cfg.AllowCredentials, _ = strconv.ParseBool(getEnv("CORS_ADMIN_ALLOW_CREDENTIALS", "true"))
+		admin, err := middlewares.NewCORSPolicy(cfg)
+		if err != nil {
+			return middlewares.CORSRules{}, fmt.Errorf("admin routes: %w", err)
+		}
+		rules.Groups["/api/v1/admin"] = admin
+	}
+
+	return rules, nil
+}
+
+// newSecurity builds the HTTP protections from the SECURITY_*, MAX_BODY_BYTES, TRUSTED_*
+// and ADMIN_*_IPS environment variables
+func newSecurity() (routes.Security, error) {
+	hstsMaxAge, _ := strconv.Atoi(getEnv("SECURITY_HSTS_MAX_AGE", "31536000"))
+	hstsIncludeSubdomains, _ := strconv.ParseBool(getEnv("SECURITY_HSTS_INCLUDE_SUBDOMAINS", "false"))
+	maxBodyBytes, _ := strconv.ParseInt(getEnv("MAX_BODY_BYTES", "1048576"), 10, 64)
+
+	// The admin routes flush caches and change log levels, so only local clients reach
+	// them unless told otherwise; 0.0.0.0/0,::/0 opens them to everyone
+	adminFilter, err := middlewares.NewIPFilter(splitList(getEnv("ADMIN_ALLOWED_IPS", "127.0.0.1,::1")), splitList(getEnv("ADMIN_DENIED_IPS", "")))
+	if err != nil {
+		return routes.Security{}, fmt.Errorf("admin IP filter: %w", err)
+	}
+
+	return routes.Security{
+		Headers: middlewares.SecurityHeadersConfig{
+			HSTSMaxAge:                      time.Duration(hstsMaxAge) * time.Second,
+			HSTSIncludeSubdomains:           hstsIncludeSubdomains,
+			ContentSecurityPolicy:           getEnv("SECURITY_CSP", ""),
+			SwaggerContentSecurityPolicy:    getEnv("SECURITY_SWAGGER_CSP", ""),
+			PlaygroundContentSecurityPolicy: getEnv("SECURITY_PLAYGROUND_CSP", ""),
+			FrameAncestors:                  splitList(getEnv("SECURITY_FRAME_ANCESTORS", "")),
+			ReferrerPolicy:                  getEnv("SECURITY_REFERRER_POLICY", ""),
+		},
+		MaxBodyBytes:    maxBodyBytes,
+		TrustedProxies:  splitList(getEnv("TRUSTED_PROXIES", "")),
+		TrustedPlatform: getEnv("TRUSTED_PLATFORM", ""),
+		Admin:           adminFilter,
+	}, nil
+}
+
+// reloadOnSIGHUP applies the LOG_LEVEL found in .env, or else in the environment, and
+// starts a new log file whenever the process receives SIGHUP
+func reloadOnSIGHUP(appLogger *logger.Logger) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		for range signals {
+			level := getEnv("LOG_LEVEL", "info")
+			if env, err := godotenv.Read(); err == nil && env["LOG_LEVEL"] != "" {
+				level = env["LOG_LEVEL"]
+			}
+
+			if err := appLogger.SetLevel(level); err != nil {
+				appLogger.Error("Ignoring invalid log level", logger.Err(err))
+			} else {
+				appLogger.Info("Reloaded log level", logger.String("level", level))
+			}
+
+			if err := appLogger.Rotate(); err != nil {
+				appLogger.Error("Failed to rotate log file", logger.Err(err))
+			}
+		}
+	}()
 }
 
 // Helper function to get environment variables with defaults
@@ -72,3 +462,30 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// getEnvInterval reads an interval in seconds, falling back to defaultSeconds when the
+// variable is malformed or not positive, as a zero interval makes a ticker panic
+func getEnvInterval(key string, defaultSeconds int) time.Duration {
+	seconds, err := strconv.Atoi(getEnv(key, strconv.Itoa(defaultSeconds)))
+	if err != nil || seconds <= 0 {
+		log.Printf("Warning: Invalid %s %q, using %d seconds", key, os.Getenv(key), defaultSeconds)
+		seconds = defaultSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// splitList parses a comma separated environment variable, ignoring empty entries
+func splitList(value string) []string {
+	return splitListOn(value, ",")
+}
+
+// splitListOn parses an environment variable separated by sep, ignoring empty entries
+func splitListOn(value, sep string) []string {
+	var result []string
+	for _, item := range strings.Split(value, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
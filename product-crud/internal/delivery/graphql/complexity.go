@@ -0,0 +1,179 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// pagedFields are the fields whose selections repeat once per item on a page
+var pagedFields = map[string]bool{
+	"products": true,
+}
+
+// complexity estimates the work a query asks for before it runs: every field costs one,
+// and the selections under a paginated field count once per requested item. Documents
+// that cannot be scored return an error, so the caller rejects them instead of running
+// them unchecked.
+func complexity(query, operationName string, variables map[string]interface{}) (int, error) {
+	doc, err := parseQuery(query)
+	if err != nil {
+		return 0, err
+	}
+
+	// Without a matching operation name score the most expensive one
+	score, found := 0, false
+	for _, op := range doc.operations {
+		if operationName != "" && op.name != operationName {
+			continue
+		}
+		found = true
+
+		// Fragment costs depend on the operation's variables, so each operation counts afresh
+		c := &complexityCounter{
+			fragments: doc.fragments,
+			costs:     make(map[string]int),
+			visiting:  make(map[string]bool),
+			variables: variables,
+			defaults:  op.defaults,
+		}
+		cost := c.selectionSet(op.selections)
+		if c.err != nil {
+			return 0, c.err
+		}
+		score = max(score, cost)
+	}
+	if !found {
+		return 0, fmt.Errorf("no operation named %q", operationName)
+	}
+	return score, nil
+}
+
+type complexityCounter struct {
+	fragments map[string][]*querySelection
+	// costs memoizes fragments so repeated spreads cannot blow up the analysis itself
+	costs     map[string]int
+	visiting  map[string]bool
+	variables map[string]interface{}
+	defaults  map[string]queryValue
+	err       error
+}
+
+func (c *complexityCounter) selectionSet(selections []*querySelection) int {
+	total := 0
+	for _, sel := range selections {
+		switch {
+		case sel.field != "":
+			cost := 1
+			if sel.selections != nil {
+				cost = saturatingAdd(cost, saturatingMul(c.selectionSet(sel.selections), c.multiplier(sel)))
+			}
+			total = saturatingAdd(total, cost)
+		case sel.spread != "":
+			total = saturatingAdd(total, c.fragment(sel.spread))
+		default:
+			total = saturatingAdd(total, c.selectionSet(sel.selections))
+		}
+	}
+	return total
+}
+
+func (c *complexityCounter) fragment(name string) int {
+	if cost, ok := c.costs[name]; ok {
+		return cost
+	}
+
+	selections, ok := c.fragments[name]
+	if !ok {
+		c.fail(fmt.Errorf("unknown fragment %q", name))
+		return 0
+	}
+	if c.visiting[name] {
+		c.fail(fmt.Errorf("fragment %q spreads itself", name))
+		return 0
+	}
+
+	c.visiting[name] = true
+	cost := c.selectionSet(selections)
+	delete(c.visiting, name)
+
+	c.costs[name] = cost
+	return cost
+}
+
+func (c *complexityCounter) fail(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+// multiplier is the page size a paginated field resolves, mirroring the clamping in Products
+func (c *complexityCounter) multiplier(field *querySelection) int {
+	if !pagedFields[field.field] {
+		return 1
+	}
+
+	first := defaultPageSize
+	if value, ok := field.arguments["first"]; ok {
+		n, err := c.intValue(value)
+		switch {
+		case errors.Is(err, errNoValue):
+		case err != nil:
+			c.fail(fmt.Errorf("first: %w", err))
+		default:
+			first = n
+		}
+	}
+
+	return min(max(first, 1), maxPageSize)
+}
+
+// errNoValue reports an argument that is null or an unset variable, which resolves to its default
+var errNoValue = errors.New("no value")
+
+// intValue resolves an Int argument the way the executor does: a variable takes the request's
+// value, or else the operation's default
+func (c *complexityCounter) intValue(value queryValue) (int, error) {
+	if value.variable != "" {
+		if v, ok := c.variables[value.variable]; ok {
+			switch n := v.(type) {
+			case nil:
+				return 0, errNoValue
+			case float64:
+				return int(n), nil
+			case int:
+				return n, nil
+			default:
+				return 0, fmt.Errorf("variable %q is not an Int", value.variable)
+			}
+		}
+		// Defaults are constants; a variable there is a validation error
+		if def, ok := c.defaults[value.variable]; ok && def.variable == "" {
+			return c.intValue(def)
+		}
+		return 0, errNoValue
+	}
+
+	switch {
+	case value.literal.kind == tokenName && value.literal.value == "null":
+		return 0, errNoValue
+	case value.literal.kind == tokenInt:
+		return strconv.Atoi(value.literal.value)
+	}
+	return 0, fmt.Errorf("%s is not an Int", value.literal)
+}
+
+func saturatingAdd(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+func saturatingMul(a, b int) int {
+	if b != 0 && a > math.MaxInt/b {
+		return math.MaxInt
+	}
+	return a * b
+}
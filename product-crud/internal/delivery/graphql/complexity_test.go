@@ -0,0 +1,180 @@
+package graphql
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestComplexity(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		operationName string
+		variables     map[string]interface{}
+		want          int
+	}{
+		{name: "single field", query: `{ category(id: "1") { name } }`, want: 2},
+		{name: "default page size", query: `{ products { edges { node { id } } } }`, want: 1 + 3*defaultPageSize},
+		{name: "literal page size", query: `{ products(first: 10) { edges { node { id } } } }`, want: 31},
+		{name: "page size capped", query: `{ products(first: 1000) { edges { node { id } } } }`, want: 1 + 3*maxPageSize},
+		{name: "page size at least one", query: `{ products(first: 0) { edges { node { id } } } }`, want: 4},
+		{name: "null page size", query: `{ products(first: null) { edges { node { id } } } }`, want: 1 + 3*defaultPageSize},
+		{name: "aliased", query: `{ all: products(first: 10) { edges { node { id } } } }`, want: 31},
+		{
+			name:      "variable page size",
+			query:     `query($n: Int) { products(first: $n) { edges { node { id } } } }`,
+			variables: map[string]interface{}{"n": float64(20)},
+			want:      61,
+		},
+		{
+			name:  "variable default",
+			query: `query($n: Int = 100) { products(first: $n) { edges { node { id } } } }`,
+			want:  301,
+		},
+		{
+			name:      "variable overrides default",
+			query:     `query($n: Int = 100) { products(first: $n) { edges { node { id } } } }`,
+			variables: map[string]interface{}{"n": float64(10)},
+			want:      31,
+		},
+		{
+			name:  "fragments",
+			query: `{ products(first: 10) { ...page } } fragment page on ProductConnection { edges { node { ...fields } } } fragment fields on Product { id name }`,
+			want:  41,
+		},
+		{
+			name:  "inline fragment and directives",
+			query: `query Q($skip: Boolean = false) { products(first: 10) @skip(if: $skip) { ... on ProductConnection { edges { node { id } } } } }`,
+			want:  31,
+		},
+		{
+			name:  "most expensive operation",
+			query: `query A { categories { id } } query B { products(first: 10) { edges { node { id } } } }`,
+			want:  31,
+		},
+		{
+			name:          "named operation",
+			query:         `query A { categories { id } } query B { products(first: 10) { edges { node { id } } } }`,
+			operationName: "A",
+			want:          2,
+		},
+		{
+			name: "strings, comments and commas",
+			query: "# list\n{ products(first: 10, category: \"shoes \\\" }\", tag: \"\"\"a \\\"\"\" } b\"\"\") {\n" +
+				"  edges { node { id, name } } # trailing\n} }",
+			want: 41,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := complexity(tt.query, tt.operationName, tt.variables)
+			if err != nil {
+				t.Fatalf("complexity returned %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("complexity = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComplexityRejectsUnscorableQueries(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		operationName string
+		variables     map[string]interface{}
+	}{
+		{name: "empty", query: ``},
+		{name: "unterminated", query: `{ products { edges { node { id } } }`},
+		{name: "unterminated string", query: `{ products(category: "shoes) { totalCount } }`},
+		{name: "type definition", query: `type Query { products: Int }`},
+		{name: "unknown fragment", query: `{ products { ...page } }`},
+		{name: "cyclic fragments", query: `{ products { ...a } } fragment a on ProductConnection { ...b } fragment b on ProductConnection { ...a }`},
+		{name: "unknown operation", query: `query A { categories { id } }`, operationName: "B"},
+		{name: "page size not an Int", query: `{ products(first: "ten") { totalCount } }`},
+		{
+			name:      "variable not an Int",
+			query:     `query($n: Int) { products(first: $n) { totalCount } }`,
+			variables: map[string]interface{}{"n": "ten"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := complexity(tt.query, tt.operationName, tt.variables); err == nil {
+				t.Errorf("complexity = %d, want an error", got)
+			}
+		})
+	}
+}
+
+func TestComplexitySaturates(t *testing.T) {
+	query := "id"
+	for i := 0; i < 10; i++ {
+		query = "products(first: 200) { edges { node { " + query + " } } }"
+	}
+
+	got, err := complexity("{ "+query+" }", "", nil)
+	if err != nil {
+		t.Fatalf("complexity returned %v", err)
+	}
+	if got != math.MaxInt {
+		t.Errorf("complexity = %d, want %d", got, math.MaxInt)
+	}
+}
+
+func TestQueryRejectsBeforeExecuting(t *testing.T) {
+	// Nil services make any resolver that runs panic, so only rejected queries are served
+	handler, err := NewHandler(nil, nil, Config{MaxComplexity: 100})
+	if err != nil {
+		t.Fatalf("NewHandler: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{name: "too complex", query: `{ products { edges { node { id } } } }`, code: "QUERY_TOO_COMPLEX"},
+		{name: "invalid", query: `{ products { missing } }`},
+		{name: "unparsable", query: `{ products {`},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(request{Query: tt.query})
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			handler.Query(c)
+
+			var response struct {
+				Data   json.RawMessage `json:"data"`
+				Errors []struct {
+					Message    string                 `json:"message"`
+					Extensions map[string]interface{} `json:"extensions"`
+				} `json:"errors"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("decoding %s: %v", w.Body.String(), err)
+			}
+			if w.Code != http.StatusOK || len(response.Errors) == 0 {
+				t.Fatalf("status %d, body %s, want errors", w.Code, w.Body.String())
+			}
+			if tt.code != "" && response.Errors[0].Extensions["code"] != tt.code {
+				t.Errorf("errors = %+v, want code %s", response.Errors, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package graphql
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+	"product-crud/api/middlewares"
+	"product-crud/internal/service"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// Config controls the limits applied to incoming queries and whether the GraphiQL
+// playground is served. Zero limits disable the corresponding check.
+type Config struct {
+	Playground    bool
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// Handler serves the GraphQL API on top of the same services as the REST API
+type Handler struct {
+	schema     *graphql.Schema
+	products   *service.ProductService
+	categories *service.CategoryService
+	config     Config
+}
+
+func NewHandler(products *service.ProductService, categories *service.CategoryService, config Config) (*Handler, error) {
+	var opts []graphql.SchemaOpt
+	if config.MaxDepth > 0 {
+		opts = append(opts, graphql.MaxDepth(config.MaxDepth))
+	}
+
+	schema, err := graphql.ParseSchema(schemaSDL, &resolver{products: products, categories: categories}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Handler{
+		schema:     schema,
+		products:   products,
+		categories: categories,
+		config:     config,
+	}, nil
+}
+
+// PlaygroundEnabled reports whether the GraphiQL page should be routed
+func (h *Handler) PlaygroundEnabled() bool {
+	return h.config.Playground
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Query executes a GraphQL request. Errors are reported in the response body, as the
+// GraphQL over HTTP convention expects; only malformed requests get a 400.
+func (h *Handler) Query(c *gin.Context) {
+	var req request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if h.config.MaxComplexity > 0 {
+		// Score only documents the executor accepts, so both read the same query
+		if errs := h.schema.ValidateWithVariables(req.Query, req.Variables); len(errs) > 0 {
+			c.JSON(http.StatusOK, &graphql.Response{Errors: errs})
+			return
+		}
+
+		score, err := complexity(req.Query, req.OperationName, req.Variables)
+		if err != nil {
+			c.JSON(http.StatusOK, &graphql.Response{Errors: []*gqlerrors.QueryError{{
+				Message:    fmt.Sprintf("query complexity could not be computed: %v", err),
+				Extensions: map[string]interface{}{"code": "QUERY_NOT_ANALYZABLE"},
+			}}})
+			return
+		}
+		if score > h.config.MaxComplexity {
+			c.JSON(http.StatusOK, &graphql.Response{Errors: []*gqlerrors.QueryError{{
+				Message: fmt.Sprintf("query complexity %d exceeds the limit of %d", score, h.config.MaxComplexity),
+				Extensions: map[string]interface{}{
+					"code":       "QUERY_TOO_COMPLEX",
+					"complexity": score,
+					"limit":      h.config.MaxComplexity,
+				},
+			}}})
+			return
+		}
+	}
+
+	ctx := withLoaders(c.Request.Context(), newLoaders(h.products, h.categories))
+	response := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Playground serves GraphiQL pointed at this endpoint. Its inline script and style carry
+// the nonce of the page's content security policy.
+func (h *Handler) Playground(c *gin.Context) {
+	html := strings.ReplaceAll(playgroundHTML, "{{nonce}}", middlewares.CSPNonce(c))
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
+}
+
+const playgroundHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Product API - GraphiQL</title>
+  <style nonce="{{nonce}}">body { margin: 0; height: 100vh; } #graphiql { height: 100vh; }</style>
+  <link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css">
+</head>
+<body>
+  <div id="graphiql">Loading...</div>
+  <script crossorigin src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+  <script nonce="{{nonce}}">
+    const fetcher = GraphiQL.createFetcher({ url: window.location.pathname });
+    ReactDOM.createRoot(document.getElementById('graphiql'))
+      .render(React.createElement(GraphiQL, { fetcher: fetcher }));
+  </script>
+</body>
+</html>
+`
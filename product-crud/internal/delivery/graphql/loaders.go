@@ -0,0 +1,32 @@
+package graphql
+
+import (
+	"context"
+	"product-crud/internal/model"
+	"product-crud/internal/service"
+	"product-crud/pkg/dataloader"
+)
+
+// loaders batch the lookups resolvers make while executing one request, so a query that
+// touches many products or categories costs one cache round trip and one query per level
+type loaders struct {
+	products   *dataloader.Loader[int, *model.ProductResponse]
+	categories *dataloader.Loader[int, *model.CategoryResponse]
+}
+
+type loadersKey struct{}
+
+func newLoaders(products *service.ProductService, categories *service.CategoryService) *loaders {
+	return &loaders{
+		products:   dataloader.New(products.GetByIDs, 0, 0),
+		categories: dataloader.New(categories.GetByIDs, 0, 0),
+	}
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,440 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// graph-gophers/graphql-go keeps its query parser internal, so complexity reads documents
+// with the small parser below. It only needs the shape of executable documents: operations,
+// fragments, selections and the arguments of each field. Handler.Query scores a document
+// only after the schema has validated it, and rejects any document this parser cannot read.
+
+// queryDocument is an executable GraphQL document
+type queryDocument struct {
+	operations []*queryOperation
+	fragments  map[string][]*querySelection
+}
+
+type queryOperation struct {
+	name string
+	// defaults are the default values of the operation's variables
+	defaults   map[string]queryValue
+	selections []*querySelection
+}
+
+// querySelection is a field when field is set, a fragment spread when spread is set and an
+// inline fragment otherwise
+type querySelection struct {
+	field      string
+	arguments  map[string]queryValue
+	spread     string
+	selections []*querySelection
+}
+
+// queryValue is a variable reference or a literal; list and object literals are kept as
+// their opening punctuator only
+type queryValue struct {
+	variable string
+	literal  queryToken
+}
+
+type queryTokenKind int
+
+const (
+	tokenEOF queryTokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type queryToken struct {
+	kind  queryTokenKind
+	value string
+}
+
+func (t queryToken) String() string {
+	if t.kind == tokenEOF {
+		return "end of document"
+	}
+	return fmt.Sprintf("%q", t.value)
+}
+
+type queryLexer struct {
+	src string
+	pos int
+}
+
+func (l *queryLexer) next() (queryToken, error) {
+	l.skipIgnored()
+	if l.pos >= len(l.src) {
+		return queryToken{kind: tokenEOF}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.pos += 3
+		return queryToken{kind: tokenPunctuator, value: "..."}, nil
+	case strings.IndexByte("!$&():=@[]{|}", c) >= 0:
+		l.pos++
+		return queryToken{kind: tokenPunctuator, value: string(c)}, nil
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return queryToken{kind: tokenName, value: l.src[start:l.pos]}, nil
+	case c == '-' || isDigit(c):
+		return l.number()
+	case c == '"':
+		return l.stringValue()
+	}
+	return queryToken{}, fmt.Errorf("unexpected character %q at offset %d", c, start)
+}
+
+// skipIgnored skips white space, commas, comments and the byte order mark
+func (l *queryLexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.src[l.pos:], "\uFEFF"):
+			l.pos += len("\uFEFF")
+		default:
+			return
+		}
+	}
+}
+
+func (l *queryLexer) number() (queryToken, error) {
+	start := l.pos
+	kind := tokenInt
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	if !l.digits() {
+		return queryToken{}, fmt.Errorf("invalid number at offset %d", start)
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokenFloat
+		l.pos++
+		if !l.digits() {
+			return queryToken{}, fmt.Errorf("invalid number at offset %d", start)
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokenFloat
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		if !l.digits() {
+			return queryToken{}, fmt.Errorf("invalid number at offset %d", start)
+		}
+	}
+	return queryToken{kind: kind, value: l.src[start:l.pos]}, nil
+}
+
+// digits consumes a run of digits and reports whether there was one
+func (l *queryLexer) digits() bool {
+	start := l.pos
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.pos++
+	}
+	return l.pos > start
+}
+
+// stringValue consumes a string or block string; its contents are never needed, so escapes are
+// skipped rather than decoded
+func (l *queryLexer) stringValue() (queryToken, error) {
+	start := l.pos
+	if strings.HasPrefix(l.src[l.pos:], `"""`) {
+		for l.pos += 3; l.pos < len(l.src); l.pos++ {
+			if strings.HasPrefix(l.src[l.pos:], `\"""`) {
+				l.pos += 3
+				continue
+			}
+			if strings.HasPrefix(l.src[l.pos:], `"""`) {
+				l.pos += 3
+				return queryToken{kind: tokenString, value: l.src[start:l.pos]}, nil
+			}
+		}
+		return queryToken{}, fmt.Errorf("unterminated string at offset %d", start)
+	}
+
+	for l.pos++; l.pos < len(l.src); l.pos++ {
+		switch l.src[l.pos] {
+		case '\\':
+			l.pos++
+		case '\n', '\r':
+			return queryToken{}, fmt.Errorf("unterminated string at offset %d", start)
+		case '"':
+			l.pos++
+			return queryToken{kind: tokenString, value: l.src[start:l.pos]}, nil
+		}
+	}
+	return queryToken{}, fmt.Errorf("unterminated string at offset %d", start)
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// queryParser is a recursive descent parser; after the first error every method returns
+// without consuming input, so callers check err once at the end
+type queryParser struct {
+	lexer queryLexer
+	tok   queryToken
+	err   error
+}
+
+// parseQuery parses an executable document, rejecting type system definitions
+func parseQuery(src string) (*queryDocument, error) {
+	p := &queryParser{lexer: queryLexer{src: src}}
+	p.advance()
+
+	doc := &queryDocument{fragments: make(map[string][]*querySelection)}
+	for p.err == nil && p.tok.kind != tokenEOF {
+		switch {
+		case p.peek("{"):
+			doc.operations = append(doc.operations, &queryOperation{selections: p.selectionSet()})
+		case p.keyword("query", "mutation", "subscription"):
+			doc.operations = append(doc.operations, p.operation())
+		case p.keyword("fragment"):
+			p.advance()
+			name := p.fragmentName()
+			p.typeCondition()
+			p.directives()
+			doc.fragments[name] = p.selectionSet()
+		default:
+			p.fail("unexpected %s", p.tok)
+		}
+	}
+
+	if p.err != nil {
+		return nil, p.err
+	}
+	if len(doc.operations) == 0 {
+		return nil, errors.New("document has no operation")
+	}
+	return doc, nil
+}
+
+func (p *queryParser) advance() {
+	if p.err != nil {
+		return
+	}
+	p.tok, p.err = p.lexer.next()
+}
+
+func (p *queryParser) fail(format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = fmt.Errorf(format, args...)
+	}
+}
+
+// peek reports whether the current token is the punctuator value
+func (p *queryParser) peek(value string) bool {
+	return p.err == nil && p.tok.kind == tokenPunctuator && p.tok.value == value
+}
+
+// keyword reports whether the current token is one of the names
+func (p *queryParser) keyword(names ...string) bool {
+	if p.err != nil || p.tok.kind != tokenName {
+		return false
+	}
+	for _, name := range names {
+		if p.tok.value == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *queryParser) expect(value string) {
+	if !p.peek(value) {
+		p.fail("expected %q, found %s", value, p.tok)
+		return
+	}
+	p.advance()
+}
+
+func (p *queryParser) name() string {
+	if p.err != nil {
+		return ""
+	}
+	if p.tok.kind != tokenName {
+		p.fail("expected a name, found %s", p.tok)
+		return ""
+	}
+	name := p.tok.value
+	p.advance()
+	return name
+}
+
+func (p *queryParser) fragmentName() string {
+	if p.keyword("on") {
+		p.fail("fragment cannot be named %q", "on")
+		return ""
+	}
+	return p.name()
+}
+
+func (p *queryParser) operation() *queryOperation {
+	p.advance()
+	op := &queryOperation{defaults: make(map[string]queryValue)}
+	if p.err == nil && p.tok.kind == tokenName {
+		op.name = p.name()
+	}
+	if p.peek("(") {
+		p.variableDefinitions(op)
+	}
+	p.directives()
+	op.selections = p.selectionSet()
+	return op
+}
+
+func (p *queryParser) variableDefinitions(op *queryOperation) {
+	p.expect("(")
+	for p.err == nil && !p.peek(")") {
+		p.expect("$")
+		name := p.name()
+		p.expect(":")
+		p.typeReference()
+		if p.peek("=") {
+			p.advance()
+			op.defaults[name] = p.value()
+		}
+		p.directives()
+	}
+	p.expect(")")
+}
+
+func (p *queryParser) typeReference() {
+	if p.peek("[") {
+		p.advance()
+		p.typeReference()
+		p.expect("]")
+	} else {
+		p.name()
+	}
+	if p.peek("!") {
+		p.advance()
+	}
+}
+
+func (p *queryParser) typeCondition() {
+	if !p.keyword("on") {
+		p.fail("expected %q, found %s", "on", p.tok)
+		return
+	}
+	p.advance()
+	p.name()
+}
+
+func (p *queryParser) directives() {
+	for p.peek("@") {
+		p.advance()
+		p.name()
+		if p.peek("(") {
+			p.arguments()
+		}
+	}
+}
+
+func (p *queryParser) arguments() map[string]queryValue {
+	arguments := make(map[string]queryValue)
+	p.expect("(")
+	for p.err == nil && !p.peek(")") {
+		name := p.name()
+		p.expect(":")
+		arguments[name] = p.value()
+	}
+	p.expect(")")
+	return arguments
+}
+
+func (p *queryParser) value() queryValue {
+	if p.err != nil {
+		return queryValue{}
+	}
+
+	tok := p.tok
+	switch {
+	case p.peek("$"):
+		p.advance()
+		return queryValue{variable: p.name()}
+	case p.peek("["):
+		p.advance()
+		for p.err == nil && !p.peek("]") {
+			p.value()
+		}
+		p.expect("]")
+	case p.peek("{"):
+		p.advance()
+		for p.err == nil && !p.peek("}") {
+			p.name()
+			p.expect(":")
+			p.value()
+		}
+		p.expect("}")
+	case tok.kind == tokenName || tok.kind == tokenInt || tok.kind == tokenFloat || tok.kind == tokenString:
+		p.advance()
+	default:
+		p.fail("expected a value, found %s", tok)
+	}
+	return queryValue{literal: tok}
+}
+
+func (p *queryParser) selectionSet() []*querySelection {
+	var selections []*querySelection
+	p.expect("{")
+	for p.err == nil && !p.peek("}") {
+		selections = append(selections, p.selection())
+	}
+	p.expect("}")
+	return selections
+}
+
+func (p *queryParser) selection() *querySelection {
+	if p.peek("...") {
+		p.advance()
+		if p.err == nil && p.tok.kind == tokenName && !p.keyword("on") {
+			sel := &querySelection{spread: p.name()}
+			p.directives()
+			return sel
+		}
+		if p.keyword("on") {
+			p.typeCondition()
+		}
+		p.directives()
+		return &querySelection{selections: p.selectionSet()}
+	}
+
+	sel := &querySelection{field: p.name()}
+	if p.peek(":") {
+		// The name read was an alias
+		p.advance()
+		sel.field = p.name()
+	}
+	if p.peek("(") {
+		sel.arguments = p.arguments()
+	}
+	p.directives()
+	if p.peek("{") {
+		sel.selections = p.selectionSet()
+	}
+	return sel
+}
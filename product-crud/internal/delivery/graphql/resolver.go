@@ -0,0 +1,371 @@
+package graphql
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"product-crud/internal/model"
+	"product-crud/internal/service"
+	"product-crud/internal/validation"
+	"product-crud/pkg/money"
+	"strconv"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+
+	cursorPrefix = "product:"
+)
+
+// resolver is the root of the schema, serving Query and Mutation from the service layer
+type resolver struct {
+	products   *service.ProductService
+	categories *service.CategoryService
+}
+
+type moneyInput struct {
+	Amount   string
+	Currency string
+}
+
+type optionInput struct {
+	Name   string
+	Values []string
+}
+
+type createProductInput struct {
+	Name        string
+	Description *string
+	Price       string
+	Currency    *string
+	Prices      *[]moneyInput
+	CategoryIds *[]graphql.ID
+	Tags        *[]string
+	Options     *[]optionInput
+}
+
+type updateProductInput struct {
+	Name        *string
+	Description *string
+	Price       *string
+	Currency    *string
+	Prices      *[]moneyInput
+	CategoryIds *[]graphql.ID
+	Tags        *[]string
+	Options     *[]optionInput
+}
+
+func (r *resolver) Product(ctx context.Context, args struct{ ID graphql.ID }) (*productResolver, error) {
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := loadersFrom(ctx).products.Load(ctx, id)
+	if err != nil {
+		return nil, toError(err)
+	}
+	if product == nil {
+		return nil, nil
+	}
+
+	return &productResolver{product: product}, nil
+}
+
+// Products pages through the products in the database with ProductService.GetPage. The
+// cursor wraps the product ID, so inserts never shift later pages, matching the gRPC
+// ListProducts page token.
+func (r *resolver) Products(ctx context.Context, args struct {
+	First    *int32
+	After    *string
+	Category *string
+	Tag      *string
+}) (*productConnectionResolver, error) {
+	first := defaultPageSize
+	if args.First != nil {
+		first = int(*args.First)
+		switch {
+		case first < 0:
+			return nil, toError(validation.Errors{{Field: "first", Code: "out_of_range", Message: "must not be negative"}})
+		case first > maxPageSize:
+			first = maxPageSize
+		}
+	}
+
+	afterID := 0
+	if args.After != nil && *args.After != "" {
+		id, err := decodeCursor(*args.After)
+		if err != nil {
+			return nil, toError(validation.Errors{{Field: "after", Code: "invalid", Message: "must be a cursor returned by a previous page"}})
+		}
+		afterID = id
+	}
+
+	filter := model.ProductFilter{}
+	if args.Tag != nil {
+		filter.Tag = *args.Tag
+	}
+	if args.Category != nil && *args.Category != "" {
+		if id, err := strconv.Atoi(*args.Category); err == nil {
+			filter.CategoryID = id
+		} else {
+			filter.CategorySlug = *args.Category
+		}
+	}
+
+	// One more than the page tells whether another page follows
+	products, err := r.products.GetPage(ctx, filter, afterID, first+1)
+	if err != nil {
+		return nil, toError(err)
+	}
+
+	connection := &productConnectionResolver{products: r.products, filter: filter}
+	for _, product := range products {
+		if len(connection.edges) == first {
+			connection.hasNextPage = true
+			break
+		}
+		connection.edges = append(connection.edges, &productEdgeResolver{product: product})
+	}
+
+	return connection, nil
+}
+
+func (r *resolver) Category(ctx context.Context, args struct{ ID graphql.ID }) (*categoryResolver, error) {
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	category, err := loadersFrom(ctx).categories.Load(ctx, id)
+	if err != nil {
+		return nil, toError(err)
+	}
+	if category == nil {
+		return nil, nil
+	}
+
+	return &categoryResolver{category: category}, nil
+}
+
+func (r *resolver) Categories(ctx context.Context) ([]*categoryResolver, error) {
+	categories, err := r.categories.GetAll(ctx)
+	if err != nil {
+		return nil, toError(err)
+	}
+
+	result := make([]*categoryResolver, 0, len(categories))
+	for _, category := range categories {
+		result = append(result, &categoryResolver{category: category})
+	}
+	return result, nil
+}
+
+func (r *resolver) CreateProduct(ctx context.Context, args struct{ Input createProductInput }) (*productResolver, error) {
+	in := args.Input
+
+	price, err := parseDecimal("price", in.Price)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &model.CreateProductRequest{
+		Name:        in.Name,
+		Description: deref(in.Description),
+		Price:       price,
+		Currency:    deref(in.Currency),
+	}
+
+	if in.Prices != nil {
+		if req.Prices, err = fromMoneyInputs("prices", *in.Prices); err != nil {
+			return nil, err
+		}
+	}
+	if in.CategoryIds != nil {
+		if req.CategoryIDs, err = parseIDs("categoryIds", *in.CategoryIds); err != nil {
+			return nil, err
+		}
+	}
+	if in.Tags != nil {
+		req.Tags = *in.Tags
+	}
+	if in.Options != nil {
+		req.Options = fromOptionInputs(*in.Options)
+	}
+
+	product, err := r.products.Create(ctx, req)
+	if err != nil {
+		return nil, toError(err)
+	}
+
+	return &productResolver{product: product}, nil
+}
+
+func (r *resolver) UpdateProduct(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input updateProductInput
+}) (*productResolver, error) {
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	in := args.Input
+	req := &model.UpdateProductRequest{
+		Name:        deref(in.Name),
+		Description: deref(in.Description),
+		Currency:    deref(in.Currency),
+	}
+
+	if in.Price != nil {
+		if req.Price, err = parseDecimal("price", *in.Price); err != nil {
+			return nil, err
+		}
+	}
+
+	// A nil slice leaves the association alone, an empty one clears it
+	if in.Prices != nil {
+		prices, err := fromMoneyInputs("prices", *in.Prices)
+		if err != nil {
+			return nil, err
+		}
+		req.Prices = append([]money.Money{}, prices...)
+	}
+	if in.CategoryIds != nil {
+		ids, err := parseIDs("categoryIds", *in.CategoryIds)
+		if err != nil {
+			return nil, err
+		}
+		req.CategoryIDs = append([]int{}, ids...)
+	}
+	if in.Tags != nil {
+		req.Tags = append([]string{}, *in.Tags...)
+	}
+	if in.Options != nil {
+		req.Options = append([]model.ProductOptionRequest{}, fromOptionInputs(*in.Options)...)
+	}
+
+	product, err := r.products.Update(ctx, id, req)
+	if err != nil {
+		return nil, toError(err)
+	}
+	if product == nil {
+		return nil, nil
+	}
+
+	return &productResolver{product: product}, nil
+}
+
+func (r *resolver) DeleteProduct(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.products.Delete(ctx, id); err != nil {
+		return false, toError(err)
+	}
+	return true, nil
+}
+
+// validationError exposes field violations under the error's extensions
+type validationError struct {
+	errs validation.Errors
+}
+
+func (e *validationError) Error() string {
+	return e.errs.Error()
+}
+
+func (e *validationError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":    "VALIDATION_FAILED",
+		"details": e.errs,
+	}
+}
+
+func toError(err error) error {
+	var verrs validation.Errors
+	if errors.As(err, &verrs) {
+		return &validationError{errs: verrs}
+	}
+	return err
+}
+
+func parseID(field string, id graphql.ID) (int, error) {
+	n, err := strconv.Atoi(string(id))
+	if err != nil || n <= 0 {
+		return 0, toError(validation.Errors{{Field: field, Code: "invalid", Message: "must be a positive integer ID"}})
+	}
+	return n, nil
+}
+
+func parseIDs(field string, ids []graphql.ID) ([]int, error) {
+	result := make([]int, 0, len(ids))
+	for i, id := range ids {
+		n, err := parseID(fmt.Sprintf("%s[%d]", field, i), id)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
+
+func parseDecimal(field, value string) (decimal.Decimal, error) {
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Zero, toError(validation.Errors{{Field: field, Code: "invalid_number", Message: "must be a decimal number"}})
+	}
+	return d, nil
+}
+
+func fromMoneyInputs(field string, list []moneyInput) ([]money.Money, error) {
+	result := make([]money.Money, 0, len(list))
+	for i, m := range list {
+		amount, err := parseDecimal(fmt.Sprintf("%s[%d].amount", field, i), m.Amount)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, money.Money{Amount: amount, Currency: m.Currency})
+	}
+	return result, nil
+}
+
+func fromOptionInputs(options []optionInput) []model.ProductOptionRequest {
+	result := make([]model.ProductOptionRequest, 0, len(options))
+	for _, o := range options {
+		result = append(result, model.ProductOptionRequest{Name: o.Name, Values: o.Values})
+	}
+	return result
+}
+
+func encodeCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(id)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	value, ok := strings.CutPrefix(string(data), cursorPrefix)
+	if !ok {
+		return 0, errors.New("invalid cursor")
+	}
+	return strconv.Atoi(value)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,340 @@
+package graphql
+
+import (
+	"context"
+	"product-crud/internal/model"
+	"product-crud/internal/service"
+	"product-crud/pkg/money"
+	"sort"
+	"strconv"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type productResolver struct {
+	product *model.ProductResponse
+}
+
+func (r *productResolver) ID() graphql.ID {
+	return graphql.ID(strconv.Itoa(r.product.ID))
+}
+
+func (r *productResolver) Name() string {
+	return r.product.Name
+}
+
+func (r *productResolver) Description() string {
+	return r.product.Description
+}
+
+func (r *productResolver) Price() *moneyResolver {
+	return &moneyResolver{money: money.New(r.product.Price, r.product.Currency)}
+}
+
+func (r *productResolver) Prices() []*moneyResolver {
+	result := make([]*moneyResolver, 0, len(r.product.Prices))
+	for _, m := range r.product.Prices {
+		result = append(result, &moneyResolver{money: m})
+	}
+	return result
+}
+
+// Categories resolves the full categories through the request's loader, so listing many
+// products costs a single category query
+func (r *productResolver) Categories(ctx context.Context) ([]*categoryResolver, error) {
+	ids := make([]int, 0, len(r.product.Categories))
+	for _, c := range r.product.Categories {
+		ids = append(ids, c.ID)
+	}
+
+	categories, err := loadersFrom(ctx).categories.LoadMany(ctx, ids)
+	if err != nil {
+		return nil, toError(err)
+	}
+
+	result := make([]*categoryResolver, 0, len(categories))
+	for _, category := range categories {
+		if category != nil {
+			result = append(result, &categoryResolver{category: category})
+		}
+	}
+	return result, nil
+}
+
+func (r *productResolver) Tags() []string {
+	return r.product.Tags
+}
+
+func (r *productResolver) Options() []*optionResolver {
+	result := make([]*optionResolver, 0, len(r.product.Options))
+	for _, o := range r.product.Options {
+		result = append(result, &optionResolver{option: o})
+	}
+	return result
+}
+
+func (r *productResolver) Variants() []*variantResolver {
+	result := make([]*variantResolver, 0, len(r.product.Variants))
+	for i := range r.product.Variants {
+		result = append(result, &variantResolver{variant: &r.product.Variants[i]})
+	}
+	return result
+}
+
+func (r *productResolver) Images() []*imageResolver {
+	result := make([]*imageResolver, 0, len(r.product.Images))
+	for i := range r.product.Images {
+		result = append(result, &imageResolver{image: &r.product.Images[i]})
+	}
+	return result
+}
+
+func (r *productResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.product.CreatedAt}
+}
+
+func (r *productResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: r.product.UpdatedAt}
+}
+
+type categoryResolver struct {
+	category *model.CategoryResponse
+}
+
+func (r *categoryResolver) ID() graphql.ID {
+	return graphql.ID(strconv.Itoa(r.category.ID))
+}
+
+func (r *categoryResolver) Name() string {
+	return r.category.Name
+}
+
+func (r *categoryResolver) Slug() string {
+	return r.category.Slug
+}
+
+func (r *categoryResolver) Path() string {
+	return r.category.Path
+}
+
+func (r *categoryResolver) Parent(ctx context.Context) (*categoryResolver, error) {
+	if r.category.ParentID == nil {
+		return nil, nil
+	}
+
+	parent, err := loadersFrom(ctx).categories.Load(ctx, *r.category.ParentID)
+	if err != nil {
+		return nil, toError(err)
+	}
+	if parent == nil {
+		return nil, nil
+	}
+
+	return &categoryResolver{category: parent}, nil
+}
+
+func (r *categoryResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.category.CreatedAt}
+}
+
+func (r *categoryResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: r.category.UpdatedAt}
+}
+
+type productConnectionResolver struct {
+	edges       []*productEdgeResolver
+	hasNextPage bool
+	// products and filter count the matching products when totalCount is asked for
+	products *service.ProductService
+	filter   model.ProductFilter
+}
+
+func (r *productConnectionResolver) Edges() []*productEdgeResolver {
+	return r.edges
+}
+
+func (r *productConnectionResolver) PageInfo() *pageInfoResolver {
+	info := &pageInfoResolver{hasNextPage: r.hasNextPage}
+	if len(r.edges) > 0 {
+		cursor := r.edges[len(r.edges)-1].Cursor()
+		info.endCursor = &cursor
+	}
+	return info
+}
+
+func (r *productConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	count, err := r.products.Count(ctx, r.filter)
+	if err != nil {
+		return 0, toError(err)
+	}
+	return int32(count), nil
+}
+
+type productEdgeResolver struct {
+	product *model.ProductResponse
+}
+
+func (r *productEdgeResolver) Cursor() string {
+	return encodeCursor(r.product.ID)
+}
+
+func (r *productEdgeResolver) Node() *productResolver {
+	return &productResolver{product: r.product}
+}
+
+type pageInfoResolver struct {
+	hasNextPage bool
+	endCursor   *string
+}
+
+func (r *pageInfoResolver) HasNextPage() bool {
+	return r.hasNextPage
+}
+
+func (r *pageInfoResolver) EndCursor() *string {
+	return r.endCursor
+}
+
+type moneyResolver struct {
+	money money.Money
+}
+
+func (r *moneyResolver) Amount() string {
+	return r.money.Amount.String()
+}
+
+func (r *moneyResolver) Currency() string {
+	return r.money.Currency
+}
+
+type optionResolver struct {
+	option model.ProductOptionResponse
+}
+
+func (r *optionResolver) Name() string {
+	return r.option.Name
+}
+
+func (r *optionResolver) Values() []string {
+	return r.option.Values
+}
+
+type attributeResolver struct {
+	name  string
+	value string
+}
+
+func (r *attributeResolver) Name() string {
+	return r.name
+}
+
+func (r *attributeResolver) Value() string {
+	return r.value
+}
+
+type variantResolver struct {
+	variant *model.VariantResponse
+}
+
+func (r *variantResolver) ID() graphql.ID {
+	return graphql.ID(strconv.Itoa(r.variant.ID))
+}
+
+func (r *variantResolver) Sku() string {
+	return r.variant.SKU
+}
+
+func (r *variantResolver) Price() *moneyResolver {
+	return &moneyResolver{money: money.New(r.variant.Price, r.variant.Currency)}
+}
+
+func (r *variantResolver) PriceOverride() bool {
+	return r.variant.PriceOverride
+}
+
+func (r *variantResolver) OptionValues() []*attributeResolver {
+	return toAttributes(r.variant.OptionValues)
+}
+
+func (r *variantResolver) Attributes() []*attributeResolver {
+	return toAttributes(r.variant.Attributes)
+}
+
+type imageResolver struct {
+	image *model.ImageResponse
+}
+
+func (r *imageResolver) ID() graphql.ID {
+	return graphql.ID(strconv.Itoa(r.image.ID))
+}
+
+func (r *imageResolver) URL() string {
+	return r.image.URL
+}
+
+func (r *imageResolver) Width() int32 {
+	return int32(r.image.Width)
+}
+
+func (r *imageResolver) Height() int32 {
+	return int32(r.image.Height)
+}
+
+func (r *imageResolver) Position() int32 {
+	return int32(r.image.Position)
+}
+
+func (r *imageResolver) IsPrimary() bool {
+	return r.image.IsPrimary
+}
+
+func (r *imageResolver) Alt() string {
+	return r.image.Alt
+}
+
+func (r *imageResolver) Status() string {
+	return r.image.Status
+}
+
+func (r *imageResolver) Variants() []*imageVariantResolver {
+	result := make([]*imageVariantResolver, 0, len(r.image.Variants))
+	for i := range r.image.Variants {
+		result = append(result, &imageVariantResolver{variant: &r.image.Variants[i]})
+	}
+	return result
+}
+
+type imageVariantResolver struct {
+	variant *model.ImageVariantResponse
+}
+
+func (r *imageVariantResolver) Name() string {
+	return r.variant.Name
+}
+
+func (r *imageVariantResolver) URL() string {
+	return r.variant.URL
+}
+
+func (r *imageVariantResolver) Width() int32 {
+	return int32(r.variant.Width)
+}
+
+func (r *imageVariantResolver) Height() int32 {
+	return int32(r.variant.Height)
+}
+
+// toAttributes turns a map into name/value pairs sorted by name, so responses are stable
+func toAttributes(m map[string]string) []*attributeResolver {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]*attributeResolver, 0, len(names))
+	for _, name := range names {
+		result = append(result, &attributeResolver{name: name, value: m[name]})
+	}
+	return result
+}
@@ -0,0 +1,386 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"product-crud/internal/model"
+	"product-crud/internal/service"
+	"product-crud/internal/validation"
+	"product-crud/pkg/money"
+	"strconv"
+
+	productv1 "product-crud/api/proto/product/v1"
+
+	"github.com/shopspring/decimal"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// ProductServer implements productv1.ProductServiceServer on top of the ProductService
+type ProductServer struct {
+	productv1.UnimplementedProductServiceServer
+
+	service *service.ProductService
+}
+
+func NewProductServer(service *service.ProductService) *ProductServer {
+	return &ProductServer{
+		service: service,
+	}
+}
+
+func (s *ProductServer) CreateProduct(ctx context.Context, req *productv1.CreateProductRequest) (*productv1.Product, error) {
+	price, err := parseDecimal("price", req.GetPrice())
+	if err != nil {
+		return nil, err
+	}
+	prices, err := fromMoneyList("prices", req.GetPrices())
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.service.Create(ctx, &model.CreateProductRequest{
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+		Price:       price,
+		Currency:    req.GetCurrency(),
+		Prices:      prices,
+		CategoryIDs: toInts(req.GetCategoryIds()),
+		Tags:        req.GetTags(),
+		Options:     fromOptions(req.GetOptions()),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toProduct(product), nil
+}
+
+func (s *ProductServer) GetProduct(ctx context.Context, req *productv1.GetProductRequest) (*productv1.Product, error) {
+	product, err := s.service.GetByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if product == nil {
+		return nil, status.Errorf(codes.NotFound, "product %d not found", req.GetId())
+	}
+
+	return toProduct(product), nil
+}
+
+// ListProducts pages through the products in the database with ProductService.GetPage.
+// Products are ordered by ID and the page token is the last ID of the previous page, so
+// inserts never shift later pages.
+func (s *ProductServer) ListProducts(ctx context.Context, req *productv1.ListProductsRequest) (*productv1.ListProductsResponse, error) {
+	pageSize := int(req.GetPageSize())
+	switch {
+	case pageSize < 0:
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	case pageSize == 0:
+		pageSize = defaultPageSize
+	case pageSize > maxPageSize:
+		pageSize = maxPageSize
+	}
+
+	afterID := 0
+	if token := req.GetPageToken(); token != "" {
+		id, err := strconv.Atoi(token)
+		if err != nil || id < 0 {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+		afterID = id
+	}
+
+	filter := model.ProductFilter{
+		Tag: req.GetTag(),
+	}
+	if category := req.GetCategory(); category != "" {
+		if id, err := strconv.Atoi(category); err == nil {
+			filter.CategoryID = id
+		} else {
+			filter.CategorySlug = category
+		}
+	}
+
+	// One more than the page tells whether another page follows
+	products, err := s.service.GetPage(ctx, filter, afterID, pageSize+1)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toPage(products, pageSize), nil
+}
+
+// toPage returns the first pageSize of products, which may hold one more to show that
+// another page follows; the page token is then the ID of the page's last product
+func toPage(products []*model.ProductResponse, pageSize int) *productv1.ListProductsResponse {
+	response := &productv1.ListProductsResponse{}
+	for _, product := range products {
+		if len(response.Products) == pageSize {
+			response.NextPageToken = strconv.Itoa(int(response.Products[pageSize-1].GetId()))
+			break
+		}
+		response.Products = append(response.Products, toProduct(product))
+	}
+	return response
+}
+
+func (s *ProductServer) UpdateProduct(ctx context.Context, req *productv1.UpdateProductRequest) (*productv1.Product, error) {
+	update := &model.UpdateProductRequest{
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+		Currency:    req.GetCurrency(),
+	}
+
+	if req.GetPrice() != "" {
+		price, err := parseDecimal("price", req.GetPrice())
+		if err != nil {
+			return nil, err
+		}
+		update.Price = price
+	}
+
+	// A nil slice leaves the association alone, an empty one clears it
+	if req.GetReplacePrices() {
+		prices, err := fromMoneyList("prices", req.GetPrices())
+		if err != nil {
+			return nil, err
+		}
+		update.Prices = append([]money.Money{}, prices...)
+	}
+	if req.GetReplaceCategories() {
+		update.CategoryIDs = append([]int{}, toInts(req.GetCategoryIds())...)
+	}
+	if req.GetReplaceTags() {
+		update.Tags = append([]string{}, req.GetTags()...)
+	}
+	if req.GetReplaceOptions() {
+		update.Options = append([]model.ProductOptionRequest{}, fromOptions(req.GetOptions())...)
+	}
+
+	product, err := s.service.Update(ctx, int(req.GetId()), update)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if product == nil {
+		return nil, status.Errorf(codes.NotFound, "product %d not found", req.GetId())
+	}
+
+	return toProduct(product), nil
+}
+
+func (s *ProductServer) DeleteProduct(ctx context.Context, req *productv1.DeleteProductRequest) (*productv1.DeleteProductResponse, error) {
+	if err := s.service.Delete(ctx, int(req.GetId())); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &productv1.DeleteProductResponse{}, nil
+}
+
+// WatchProducts streams changes made through this instance until the client goes away
+func (s *ProductServer) WatchProducts(req *productv1.WatchProductsRequest, stream productv1.ProductService_WatchProductsServer) error {
+	ctx := stream.Context()
+
+	var only map[int]bool
+	if len(req.GetProductIds()) > 0 {
+		only = make(map[int]bool, len(req.GetProductIds()))
+		for _, id := range req.GetProductIds() {
+			only[int(id)] = true
+		}
+	}
+
+	for event := range s.service.Subscribe(ctx) {
+		if only != nil && !only[event.ProductID] {
+			continue
+		}
+
+		msg := &productv1.ProductEvent{
+			Type:       toEventType(event.Type),
+			ProductId:  int64(event.ProductID),
+			OccurredAt: timestamppb.New(event.OccurredAt),
+		}
+
+		if event.Type != service.ProductDeleted {
+			product, err := s.service.GetByID(ctx, event.ProductID)
+			if err != nil {
+				return toStatus(err)
+			}
+			if product == nil {
+				// Deleted since the event was published; the deletion event follows
+				continue
+			}
+			msg.Product = toProduct(product)
+		}
+
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+	}
+
+	return status.FromContextError(ctx.Err()).Err()
+}
+
+// toStatus maps service errors to gRPC statuses, attaching field violations to validation errors
+func toStatus(err error) error {
+	var verrs validation.Errors
+	if !errors.As(err, &verrs) {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return status.FromContextError(err).Err()
+		}
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(verrs))
+	for _, fe := range verrs {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       fe.Field,
+			Description: fe.Code + ": " + fe.Message,
+		})
+	}
+
+	st, detailErr := status.New(codes.InvalidArgument, "validation failed").
+		WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if detailErr != nil {
+		return status.Error(codes.InvalidArgument, verrs.Error())
+	}
+	return st.Err()
+}
+
+func parseDecimal(field, value string) (decimal.Decimal, error) {
+	if value == "" {
+		return decimal.Zero, nil
+	}
+
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Zero, toStatus(validation.Errors{{Field: field, Code: "invalid_number", Message: "must be a decimal number"}})
+	}
+	return d, nil
+}
+
+func fromMoneyList(field string, list []*productv1.Money) ([]money.Money, error) {
+	if len(list) == 0 {
+		return nil, nil
+	}
+
+	result := make([]money.Money, 0, len(list))
+	for i, m := range list {
+		amount, err := parseDecimal(field+"["+strconv.Itoa(i)+"].amount", m.GetAmount())
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, money.Money{Amount: amount, Currency: m.GetCurrency()})
+	}
+	return result, nil
+}
+
+func fromOptions(options []*productv1.ProductOption) []model.ProductOptionRequest {
+	if len(options) == 0 {
+		return nil
+	}
+
+	result := make([]model.ProductOptionRequest, 0, len(options))
+	for _, o := range options {
+		result = append(result, model.ProductOptionRequest{Name: o.GetName(), Values: o.GetValues()})
+	}
+	return result
+}
+
+func toInts(ids []int64) []int {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	result := make([]int, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, int(id))
+	}
+	return result
+}
+
+func toEventType(eventType string) productv1.ProductEvent_Type {
+	switch eventType {
+	case service.ProductCreated:
+		return productv1.ProductEvent_TYPE_CREATED
+	case service.ProductUpdated:
+		return productv1.ProductEvent_TYPE_UPDATED
+	case service.ProductDeleted:
+		return productv1.ProductEvent_TYPE_DELETED
+	default:
+		return productv1.ProductEvent_TYPE_UNSPECIFIED
+	}
+}
+
+func toMoney(m money.Money) *productv1.Money {
+	return &productv1.Money{Amount: m.Amount.String(), Currency: m.Currency}
+}
+
+func toProduct(p *model.ProductResponse) *productv1.Product {
+	product := &productv1.Product{
+		Id:          int64(p.ID),
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       toMoney(money.New(p.Price, p.Currency)),
+		Tags:        p.Tags,
+		CreatedAt:   timestamppb.New(p.CreatedAt),
+		UpdatedAt:   timestamppb.New(p.UpdatedAt),
+	}
+
+	for _, m := range p.Prices {
+		product.Prices = append(product.Prices, toMoney(m))
+	}
+
+	for _, c := range p.Categories {
+		product.Categories = append(product.Categories, &productv1.CategorySummary{
+			Id:   int64(c.ID),
+			Name: c.Name,
+			Slug: c.Slug,
+			Path: c.Path,
+		})
+	}
+
+	for _, o := range p.Options {
+		product.Options = append(product.Options, &productv1.ProductOption{Name: o.Name, Values: o.Values})
+	}
+
+	for _, v := range p.Variants {
+		product.Variants = append(product.Variants, &productv1.Variant{
+			Id:            int64(v.ID),
+			Sku:           v.SKU,
+			Price:         toMoney(money.New(v.Price, v.Currency)),
+			PriceOverride: v.PriceOverride,
+			OptionValues:  v.OptionValues,
+			Attributes:    v.Attributes,
+		})
+	}
+
+	for _, img := range p.Images {
+		image := &productv1.Image{
+			Id:        int64(img.ID),
+			Url:       img.URL,
+			Width:     int32(img.Width),
+			Height:    int32(img.Height),
+			Position:  int32(img.Position),
+			IsPrimary: img.IsPrimary,
+			Alt:       img.Alt,
+		}
+		for _, v := range img.Variants {
+			image.Variants = append(image.Variants, &productv1.ImageVariant{
+				Name:   v.Name,
+				Url:    v.URL,
+				Width:  int32(v.Width),
+				Height: int32(v.Height),
+			})
+		}
+		product.Images = append(product.Images, image)
+	}
+
+	return product
+}
@@ -0,0 +1,52 @@
+package grpc
+
+import (
+	"product-crud/internal/model"
+	"testing"
+)
+
+// products returns responses for the given IDs, in order
+func products(ids ...int) []*model.ProductResponse {
+	result := make([]*model.ProductResponse, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, &model.ProductResponse{ID: id, Currency: "USD"})
+	}
+	return result
+}
+
+func TestToPage(t *testing.T) {
+	tests := []struct {
+		name      string
+		products  []*model.ProductResponse
+		pageSize  int
+		wantIDs   []int64
+		wantToken string
+	}{
+		{name: "extra row", products: products(3, 5, 8), pageSize: 2, wantIDs: []int64{3, 5}, wantToken: "5"},
+		{name: "last page, full", products: products(3, 5), pageSize: 2, wantIDs: []int64{3, 5}, wantToken: ""},
+		{name: "last page, short", products: products(8), pageSize: 2, wantIDs: []int64{8}, wantToken: ""},
+		{name: "empty", products: nil, pageSize: 2, wantIDs: nil, wantToken: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := toPage(tt.products, tt.pageSize)
+
+			var ids []int64
+			for _, p := range page.GetProducts() {
+				ids = append(ids, p.GetId())
+			}
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("products = %v, want %v", ids, tt.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Fatalf("products = %v, want %v", ids, tt.wantIDs)
+				}
+			}
+			if page.GetNextPageToken() != tt.wantToken {
+				t.Errorf("next page token = %q, want %q", page.GetNextPageToken(), tt.wantToken)
+			}
+		})
+	}
+}
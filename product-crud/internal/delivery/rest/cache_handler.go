@@ -0,0 +1,134 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"product-crud/internal/service"
+	"product-crud/pkg/cache"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CacheHandler struct {
+	service *service.CacheService
+}
+
+func NewCacheHandler(service *service.CacheService) *CacheHandler {
+	return &CacheHandler{
+		service: service,
+	}
+}
+
+// GetCacheStats godoc
+// @Summary Get cache statistics
+// @Description Get this instance's hit, miss and eviction counts per key namespace, along with the Redis server's own counters
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Success 200 {object} cache.Stats
+// @Failure 500 {string} string "Internal Server Error"
+// @Failure 503 {string} string "Service Unavailable"
+// @Router /admin/cache/stats [get]
+func (h *CacheHandler) GetCacheStats(c *gin.Context) {
+	stats, err := h.service.Stats(c.Request.Context())
+	if err != nil {
+		writeCacheError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// InspectCacheKey godoc
+// @Summary Inspect a cache key
+// @Description Get the value cached under a key, its remaining TTL, staleness and version
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param key query string true "Cache key, e.g. product:42"
+// @Success 200 {object} cache.KeyInfo
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Failure 503 {string} string "Service Unavailable"
+// @Router /admin/cache/keys [get]
+func (h *CacheHandler) InspectCacheKey(c *gin.Context) {
+	info, err := h.service.Inspect(c.Request.Context(), c.Query("key"))
+	if err != nil {
+		writeCacheError(c, err)
+		return
+	}
+	if info == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Key not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// FlushCache godoc
+// @Summary Flush the cache
+// @Description Remove the cached keys of a namespace, such as product, or those matching a Redis glob pattern, from Redis and every instance's local cache
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param namespace query string false "Key namespace, the part of a key before its first colon"
+// @Param pattern query string false "Redis glob pattern, e.g. products:list:*"
+// @Success 200 {object} model.FlushCacheResponse
+// @Failure 400 {string} string "Bad Request"
+// @Failure 500 {string} string "Internal Server Error"
+// @Failure 503 {string} string "Service Unavailable"
+// @Router /admin/cache [delete]
+func (h *CacheHandler) FlushCache(c *gin.Context) {
+	result, err := h.service.Flush(c.Request.Context(), c.Query("namespace"), c.Query("pattern"))
+	if err != nil {
+		writeCacheError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// WarmUpCache godoc
+// @Summary Warm up the cache
+// @Description Load the most requested products into the cache
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param count query int false "How many products to load" default(100)
+// @Success 200 {object} model.WarmUpCacheResponse
+// @Failure 400 {string} string "Bad Request"
+// @Failure 500 {string} string "Internal Server Error"
+// @Failure 503 {string} string "Service Unavailable"
+// @Router /admin/cache/warmup [post]
+func (h *CacheHandler) WarmUpCache(c *gin.Context) {
+	count, err := strconv.Atoi(c.DefaultQuery("count", "100"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid count"})
+		return
+	}
+
+	result, err := h.service.WarmUp(c.Request.Context(), count)
+	if err != nil {
+		writeCacheError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func writeCacheError(c *gin.Context, err error) {
+	if writeValidationError(c, err) {
+		return
+	}
+	if errors.Is(err, service.ErrCacheDisabled) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cache is not enabled"})
+		return
+	}
+	if errors.Is(err, cache.ErrUnavailable) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cache is unavailable"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
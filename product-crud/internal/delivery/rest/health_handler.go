@@ -0,0 +1,38 @@
+package rest
+
+import (
+	"net/http"
+	"product-crud/internal/model"
+	"product-crud/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	cacheService *service.CacheService
+}
+
+func NewHealthHandler(cacheService *service.CacheService) *HealthHandler {
+	return &HealthHandler{
+		cacheService: cacheService,
+	}
+}
+
+// Health godoc
+// @Summary Check service health
+// @Description Report whether the service is up, and the state of the circuit breaker in front of Redis. The status is degraded, still with a 200, while the breaker is not closed.
+// @Tags health
+// @Produce json
+// @Success 200 {object} model.HealthResponse
+// @Router /health [get]
+func (h *HealthHandler) Health(c *gin.Context) {
+	response := model.HealthResponse{
+		Status: "OK",
+		Cache:  h.cacheService.Breaker(),
+	}
+	if response.Cache != nil && response.Cache.State != "closed" {
+		response.Status = "degraded"
+	}
+
+	c.JSON(http.StatusOK, response)
+}
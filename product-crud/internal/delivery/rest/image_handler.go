@@ -0,0 +1,290 @@
+package rest
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"product-crud/internal/model"
+	"product-crud/internal/service"
+	"product-crud/pkg/storage"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead leaves room for form fields and boundaries on top of the file itself
+const multipartOverhead = 1 << 20
+
+type ImageHandler struct {
+	service *service.ImageService
+}
+
+func NewImageHandler(service *service.ImageService) *ImageHandler {
+	return &ImageHandler{
+		service: service,
+	}
+}
+
+// UploadImage godoc
+// @Summary Upload a product image
+// @Description Upload a JPEG, PNG, GIF or WebP image; the type is detected from the file contents
+// @Tags images
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param file formData file true "Image file"
+// @Param position formData int false "Position in the gallery, appended at the end by default"
+// @Param is_primary formData bool false "Make this the primary image"
+// @Param alt formData string false "Alternative text"
+// @Success 201 {object} model.ImageResponse
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Failure 413 {string} string "Request Entity Too Large"
+// @Failure 415 {string} string "Unsupported Media Type"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /products/{id}/images [post]
+func (h *ImageHandler) UploadImage(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.service.MaxSize()+multipartOverhead)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": service.ErrImageTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A file field named \"file\" is required"})
+		return
+	}
+
+	opts := service.UploadImageOptions{
+		Alt: c.PostForm("alt"),
+	}
+	if position := c.PostForm("position"); position != "" {
+		p, err := strconv.Atoi(position)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid position"})
+			return
+		}
+		opts.Position = &p
+	}
+	if primary := c.PostForm("is_primary"); primary != "" {
+		opts.IsPrimary, err = strconv.ParseBool(primary)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid is_primary flag"})
+			return
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	img, err := h.service.Upload(c.Request.Context(), productID, file, opts)
+	if err != nil {
+		switch {
+		case writeValidationError(c, err):
+		case errors.Is(err, service.ErrImageTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUnsupportedImageType):
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if img == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, img)
+}
+
+// GetImages godoc
+// @Summary Get the images of a product
+// @Description Get the images of a product in gallery order
+// @Tags images
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {array} model.ImageResponse
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /products/{id}/images [get]
+func (h *ImageHandler) GetImages(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	images, err := h.service.GetAll(c.Request.Context(), productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if images == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, images)
+}
+
+// UpdateImage godoc
+// @Summary Update a product image
+// @Description Change the position, primary flag or alternative text of an image
+// @Tags images
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param imageId path int true "Image ID"
+// @Param image body model.UpdateImageRequest true "Image information"
+// @Success 200 {object} model.ImageResponse
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /products/{id}/images/{imageId} [put]
+func (h *ImageHandler) UpdateImage(c *gin.Context) {
+	productID, id, ok := imageIDs(c)
+	if !ok {
+		return
+	}
+
+	var req model.UpdateImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	img, err := h.service.Update(c.Request.Context(), productID, id, &req)
+	if err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if img == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, img)
+}
+
+// DeleteImage godoc
+// @Summary Delete a product image
+// @Description Delete an image, its stored file and its generated variants
+// @Tags images
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param imageId path int true "Image ID"
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /products/{id}/images/{imageId} [delete]
+func (h *ImageHandler) DeleteImage(c *gin.Context) {
+	productID, id, ok := imageIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), productID, id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RegenerateImages godoc
+// @Summary Regenerate image variants
+// @Description Queue images for variant regeneration, e.g. after the size presets changed. Without product_id every image is queued.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param product_id query int false "Only regenerate the images of this product"
+// @Success 202 {object} model.RegenerateImagesResponse
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /admin/images/regenerate [post]
+func (h *ImageHandler) RegenerateImages(c *gin.Context) {
+	productID := 0
+	if value := c.Query("product_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+		productID = id
+	}
+
+	queued, err := h.service.Regenerate(c.Request.Context(), productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if queued < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, model.RegenerateImagesResponse{Queued: queued})
+}
+
+// ServeMedia streams a stored media file
+func (h *ImageHandler) ServeMedia(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	body, obj, err := h.service.OpenMedia(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Media not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	contentType := obj.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// Keys are never reused, so media can be cached indefinitely
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Last-Modified", obj.LastModified.UTC().Format(http.TimeFormat))
+	c.DataFromReader(http.StatusOK, obj.Size, contentType, io.NopCloser(body), nil)
+}
+
+func imageIDs(c *gin.Context) (int, int, bool) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return 0, 0, false
+	}
+
+	id, err := strconv.Atoi(c.Param("imageId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image ID"})
+		return 0, 0, false
+	}
+
+	return productID, id, true
+}
@@ -0,0 +1,271 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"product-crud/internal/model"
+	"product-crud/internal/repository"
+	"product-crud/internal/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type InventoryHandler struct {
+	service *service.InventoryService
+}
+
+func NewInventoryHandler(service *service.InventoryService) *InventoryHandler {
+	return &InventoryHandler{
+		service: service,
+	}
+}
+
+// GetStock godoc
+// @Summary Get the stock of a product
+// @Description Get the on-hand, reserved and available quantities of a product
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {object} model.StockResponse
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /products/{id}/stock [get]
+func (h *InventoryHandler) GetStock(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	stock, err := h.service.GetStock(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if stock == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stock)
+}
+
+// AdjustStock godoc
+// @Summary Adjust the stock of a product
+// @Description Add or remove on-hand units and record the movement in the stock ledger
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param adjustment body model.AdjustStockRequest true "Stock adjustment"
+// @Success 200 {object} model.StockResponse
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /products/{id}/stock/adjustments [post]
+func (h *InventoryHandler) AdjustStock(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req model.AdjustStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stock, err := h.service.Adjust(c.Request.Context(), id, &req)
+	if err != nil {
+		writeInventoryError(c, err)
+		return
+	}
+	if stock == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stock)
+}
+
+// GetStockLedger godoc
+// @Summary Get the stock ledger of a product
+// @Description Get the latest stock movements of a product, newest first
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {array} model.StockMovement
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /products/{id}/stock/ledger [get]
+func (h *InventoryHandler) GetStockLedger(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	movements, err := h.service.GetLedger(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if movements == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, movements)
+}
+
+// CreateReservation godoc
+// @Summary Reserve stock of a product
+// @Description Hold units of a product until the reservation is committed, released or expires
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param reservation body model.CreateReservationRequest true "Reservation"
+// @Success 201 {object} model.ReservationResponse
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /products/{id}/reservations [post]
+func (h *InventoryHandler) CreateReservation(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req model.CreateReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reservation, err := h.service.Reserve(c.Request.Context(), id, &req)
+	if err != nil {
+		writeInventoryError(c, err)
+		return
+	}
+	if reservation == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, reservation)
+}
+
+// GetReservation godoc
+// @Summary Get a reservation
+// @Description Get a stock reservation by its ID
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path string true "Reservation ID"
+// @Success 200 {object} model.ReservationResponse
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /reservations/{id} [get]
+func (h *InventoryHandler) GetReservation(c *gin.Context) {
+	id, ok := reservationID(c)
+	if !ok {
+		return
+	}
+
+	reservation, err := h.service.GetReservation(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if reservation == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reservation not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+// CommitReservation godoc
+// @Summary Commit a reservation
+// @Description Turn an active reservation into a sale, removing its units from stock. An expired reservation is released instead and refused with 409.
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path string true "Reservation ID"
+// @Success 200 {object} model.ReservationResponse
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /reservations/{id}/commit [post]
+func (h *InventoryHandler) CommitReservation(c *gin.Context) {
+	id, ok := reservationID(c)
+	if !ok {
+		return
+	}
+
+	reservation, err := h.service.CommitReservation(c.Request.Context(), id)
+	if err != nil {
+		writeInventoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+// ReleaseReservation godoc
+// @Summary Release a reservation
+// @Description Return the units of an active reservation to available stock
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path string true "Reservation ID"
+// @Success 200 {object} model.ReservationResponse
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /reservations/{id} [delete]
+func (h *InventoryHandler) ReleaseReservation(c *gin.Context) {
+	id, ok := reservationID(c)
+	if !ok {
+		return
+	}
+
+	reservation, err := h.service.ReleaseReservation(c.Request.Context(), id)
+	if err != nil {
+		writeInventoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+func reservationID(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reservation ID"})
+		return "", false
+	}
+	return id, true
+}
+
+func writeInventoryError(c *gin.Context, err error) {
+	switch {
+	case writeValidationError(c, err):
+	case errors.Is(err, repository.ErrReservationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reservation not found"})
+	case errors.Is(err, repository.ErrInsufficientStock), errors.Is(err, repository.ErrReservationClosed),
+		errors.Is(err, repository.ErrReservationExpired):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,58 @@
+package rest
+
+import (
+	"net/http"
+	"product-crud/internal/model"
+	"product-crud/internal/validation"
+	"product-crud/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LogHandler struct {
+	logger *logger.Logger
+}
+
+func NewLogHandler(logger *logger.Logger) *LogHandler {
+	return &LogHandler{
+		logger: logger,
+	}
+}
+
+// GetLogLevel godoc
+// @Summary Get the log level
+// @Description Get the level of the application logger
+// @Tags admin
+// @Produce json
+// @Success 200 {object} model.LogLevel
+// @Router /admin/log-level [get]
+func (h *LogHandler) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, model.LogLevel{Level: h.logger.Level()})
+}
+
+// SetLogLevel godoc
+// @Summary Change the log level
+// @Description Change the level of the application logger until the next change, SIGHUP or restart
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param level body model.LogLevel true "New level"
+// @Success 200 {object} model.LogLevel
+// @Failure 400 {string} string "Bad Request"
+// @Router /admin/log-level [put]
+func (h *LogHandler) SetLogLevel(c *gin.Context) {
+	var req model.LogLevel
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	previous := h.logger.Level()
+	if err := h.logger.SetLevel(req.Level); err != nil {
+		writeValidationError(c, validation.Errors{{Field: "level", Code: "invalid", Message: err.Error()}})
+		return
+	}
+	h.logger.Ctx(c.Request.Context()).Info("Changed log level", logger.String("from", previous), logger.String("to", req.Level))
+
+	c.JSON(http.StatusOK, model.LogLevel{Level: h.logger.Level()})
+}
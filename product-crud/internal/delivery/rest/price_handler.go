@@ -0,0 +1,180 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"product-crud/internal/model"
+	"product-crud/internal/repository"
+	"product-crud/internal/service"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PriceHandler struct {
+	service *service.PriceService
+}
+
+func NewPriceHandler(service *service.PriceService) *PriceHandler {
+	return &PriceHandler{
+		service: service,
+	}
+}
+
+// SchedulePrice godoc
+// @Summary Schedule a price change
+// @Description Schedule a product's price to change at effective_from. With effective_to the price is temporary and reverts to the previous one afterwards.
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param price body model.SchedulePriceRequest true "Scheduled price"
+// @Success 201 {object} model.PriceHistoryResponse
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /products/{id}/prices [post]
+func (h *PriceHandler) SchedulePrice(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req model.SchedulePriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	price, err := h.service.Schedule(c.Request.Context(), id, &req)
+	if err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if price == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, price)
+}
+
+// GetPriceHistory godoc
+// @Summary Get the price history of a product
+// @Description Get the past, current and scheduled prices of a product, latest first
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {array} model.PriceHistoryResponse
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /products/{id}/prices [get]
+func (h *PriceHandler) GetPriceHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	prices, err := h.service.GetHistory(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if prices == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, prices)
+}
+
+// GetPriceAt godoc
+// @Summary Get the price of a product at a point in time
+// @Description Get the price that was, is or will be in effect at the given time
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param at query string false "RFC 3339 timestamp, defaults to now"
+// @Success 200 {object} model.PriceAtResponse
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /products/{id}/price [get]
+func (h *PriceHandler) GetPriceAt(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	at := time.Now()
+	if value := c.Query("at"); value != "" {
+		at, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time, expected RFC 3339"})
+			return
+		}
+	}
+
+	price, err := h.service.PriceAt(c.Request.Context(), id, at)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if price == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No price in effect at that time"})
+		return
+	}
+
+	c.JSON(http.StatusOK, price)
+}
+
+// CancelPrice godoc
+// @Summary Cancel a scheduled price change
+// @Description Delete a scheduled price that has not taken effect yet
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param priceId path int true "Price history entry ID"
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /products/{id}/prices/{priceId} [delete]
+func (h *PriceHandler) CancelPrice(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("priceId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price ID"})
+		return
+	}
+
+	if err := h.service.Cancel(c.Request.Context(), productID, id); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrPriceNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Price not found"})
+		case errors.Is(err, repository.ErrPriceAlreadyApplied):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
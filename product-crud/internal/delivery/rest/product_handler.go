@@ -1,10 +1,11 @@
 package rest
 
 import (
-	"context"
+	"errors"
 	"net/http"
 	"product-crud/internal/model"
 	"product-crud/internal/service"
+	"product-crud/internal/validation"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -38,8 +39,11 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := h.service.Create(context.Background(), &req)
+	product, err := h.service.Create(c.Request.Context(), &req)
 	if err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -65,7 +69,7 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := h.service.GetByID(context.Background(), id)
+	product, err := h.service.GetByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -80,15 +84,28 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 
 // GetProducts godoc
 // @Summary Get all products
-// @Description Get a list of all products
+// @Description Get a list of all products, optionally filtered by category (including its descendants) and tag
 // @Tags products
 // @Accept json
 // @Produce json
+// @Param category query string false "Category ID or slug"
+// @Param tag query string false "Tag"
 // @Success 200 {array} model.ProductResponse
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /products [get]
 func (h *ProductHandler) GetProducts(c *gin.Context) {
-	products, err := h.service.GetAll(context.Background())
+	filter := model.ProductFilter{
+		Tag: c.Query("tag"),
+	}
+	if category := c.Query("category"); category != "" {
+		if id, err := strconv.Atoi(category); err == nil {
+			filter.CategoryID = id
+		} else {
+			filter.CategorySlug = category
+		}
+	}
+
+	products, err := h.service.GetAll(c.Request.Context(), filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -123,8 +140,11 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := h.service.Update(context.Background(), id, &req)
+	product, err := h.service.Update(c.Request.Context(), id, &req)
 	if err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -154,11 +174,25 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	err = h.service.Delete(context.Background(), id)
+	err = h.service.Delete(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.Status(http.StatusNoContent)
-}
\ No newline at end of file
+}
+
+// writeValidationError responds with 400 and the list of field violations if err is a validation failure
+func writeValidationError(c *gin.Context, err error) bool {
+	var verrs validation.Errors
+	if !errors.As(err, &verrs) {
+		return false
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":   "Validation failed",
+		"details": verrs,
+	})
+	return true
+}
@@ -0,0 +1,240 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"product-crud/internal/model"
+	"product-crud/internal/repository"
+	"product-crud/internal/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VariantHandler struct {
+	service *service.VariantService
+}
+
+func NewVariantHandler(service *service.VariantService) *VariantHandler {
+	return &VariantHandler{
+		service: service,
+	}
+}
+
+// CreateVariant godoc
+// @Summary Create a product variant
+// @Description Create a variant with its own SKU, option values, price override and attributes
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param variant body model.VariantRequest true "Variant information"
+// @Success 201 {object} model.VariantResponse
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /products/{id}/variants [post]
+func (h *VariantHandler) CreateVariant(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req model.VariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	variant, err := h.service.Create(c.Request.Context(), productID, &req)
+	if err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if variant == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, variant)
+}
+
+// GetVariants godoc
+// @Summary Get the variants of a product
+// @Description Get every variant of a product with its resolved price
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {array} model.VariantResponse
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /products/{id}/variants [get]
+func (h *VariantHandler) GetVariants(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	variants, err := h.service.GetAll(c.Request.Context(), productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if variants == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, variants)
+}
+
+// GetVariant godoc
+// @Summary Get a product variant
+// @Description Get a variant of a product by its ID
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param variantId path int true "Variant ID"
+// @Success 200 {object} model.VariantResponse
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /products/{id}/variants/{variantId} [get]
+func (h *VariantHandler) GetVariant(c *gin.Context) {
+	productID, id, ok := variantIDs(c)
+	if !ok {
+		return
+	}
+
+	variant, err := h.service.GetByID(c.Request.Context(), productID, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if variant == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Variant not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, variant)
+}
+
+// UpdateVariant godoc
+// @Summary Replace a product variant
+// @Description Replace the SKU, option values, price override and attributes of a variant
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param variantId path int true "Variant ID"
+// @Param variant body model.VariantRequest true "Variant information"
+// @Success 200 {object} model.VariantResponse
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /products/{id}/variants/{variantId} [put]
+func (h *VariantHandler) UpdateVariant(c *gin.Context) {
+	productID, id, ok := variantIDs(c)
+	if !ok {
+		return
+	}
+
+	var req model.VariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	variant, err := h.service.Update(c.Request.Context(), productID, id, &req)
+	if err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if variant == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Variant not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, variant)
+}
+
+// DeleteVariant godoc
+// @Summary Delete a product variant
+// @Description Delete a variant of a product
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param variantId path int true "Variant ID"
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /products/{id}/variants/{variantId} [delete]
+func (h *VariantHandler) DeleteVariant(c *gin.Context) {
+	productID, id, ok := variantIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), productID, id); err != nil {
+		if errors.Is(err, repository.ErrVariantNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Variant not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetProductBySKU godoc
+// @Summary Get a product by variant SKU
+// @Description Get the parent product together with the variant a SKU resolves to
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param sku path string true "Variant SKU"
+// @Success 200 {object} model.SKULookupResponse
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /products/sku/{sku} [get]
+func (h *VariantHandler) GetProductBySKU(c *gin.Context) {
+	result, err := h.service.GetBySKU(c.Request.Context(), c.Param("sku"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if result == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "SKU not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func variantIDs(c *gin.Context) (int, int, bool) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return 0, 0, false
+	}
+
+	id, err := strconv.Atoi(c.Param("variantId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant ID"})
+		return 0, 0, false
+	}
+
+	return productID, id, true
+}
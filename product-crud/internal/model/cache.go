@@ -0,0 +1,13 @@
+package model
+
+// FlushCacheResponse reports how many keys a cache flush removed
+type FlushCacheResponse struct {
+	Pattern string `json:"pattern"`
+	Deleted int64  `json:"deleted"`
+}
+
+// WarmUpCacheResponse reports how many of the most requested products are now cached
+type WarmUpCacheResponse struct {
+	Requested int `json:"requested"`
+	Cached    int `json:"cached"`
+}
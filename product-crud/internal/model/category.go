@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+)
+
+// Category is a node in the category tree. Path holds the materialized chain of
+// ancestor IDs including the category itself, e.g. "/1/4/9/", so a subtree can be
+// selected with a single prefix match.
+type Category struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	ParentID  *int      `json:"parent_id" gorm:"index"`
+	Name      string    `json:"name" gorm:"not null"`
+	Slug      string    `json:"slug" gorm:"not null;uniqueIndex"`
+	Path      string    `json:"path" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// ProductTag is a free-form label attached to a product
+type ProductTag struct {
+	ProductID int    `json:"-" gorm:"primaryKey"`
+	Tag       string `json:"tag" gorm:"primaryKey;index"`
+}
+
+type CreateCategoryRequest struct {
+	Name     string `json:"name" validate:"required,notblank,max=100"`
+	Slug     string `json:"slug" validate:"required,slug,max=100"`
+	ParentID *int   `json:"parent_id,omitempty" validate:"omitempty,gt=0"`
+}
+
+type UpdateCategoryRequest struct {
+	Name string `json:"name,omitempty" validate:"omitempty,notblank,max=100"`
+	Slug string `json:"slug,omitempty" validate:"omitempty,slug,max=100"`
+	// ParentID moves the category under another parent; 0 moves it to the root
+	ParentID *int `json:"parent_id,omitempty" validate:"omitempty,gte=0"`
+}
+
+type CategoryResponse struct {
+	ID        int       `json:"id"`
+	ParentID  *int      `json:"parent_id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CategorySummary is the short form of a category embedded in product payloads
+type CategorySummary struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Path string `json:"path"`
+}
+
+// ProductFilter narrows product listings. Category matches by ID or slug and
+// includes products of all descendant categories.
+type ProductFilter struct {
+	CategoryID   int
+	CategorySlug string
+	Tag          string
+}
+
+// IsEmpty reports whether the filter selects every product
+func (f ProductFilter) IsEmpty() bool {
+	return f.CategoryID == 0 && f.CategorySlug == "" && f.Tag == ""
+}
@@ -0,0 +1,10 @@
+package model
+
+import "product-crud/pkg/cache"
+
+// HealthResponse reports whether the service is healthy. Status is degraded while the
+// cache is unavailable, as requests are then served straight from the database.
+type HealthResponse struct {
+	Status string              `json:"status"`
+	Cache  *cache.BreakerStats `json:"cache,omitempty"`
+}
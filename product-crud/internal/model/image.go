@@ -0,0 +1,82 @@
+package model
+
+import (
+	"time"
+)
+
+// Variant generation states of a ProductImage
+const (
+	ImageStatusPending    = "pending"
+	ImageStatusProcessing = "processing"
+	ImageStatusReady      = "ready"
+	ImageStatusFailed     = "failed"
+)
+
+// ProductImage is the metadata of an uploaded product image. The file itself lives
+// in blob storage under StorageKey.
+type ProductImage struct {
+	ID          int       `json:"id" gorm:"primaryKey"`
+	ProductID   int       `json:"product_id" gorm:"not null;index"`
+	StorageKey  string    `json:"-" gorm:"not null;uniqueIndex"`
+	ContentType string    `json:"content_type" gorm:"not null"`
+	Size        int64     `json:"size" gorm:"not null"`
+	Width       int       `json:"width" gorm:"not null;default:0"`
+	Height      int       `json:"height" gorm:"not null;default:0"`
+	Position    int       `json:"position" gorm:"not null;default:0"`
+	IsPrimary   bool      `json:"is_primary" gorm:"not null;default:false"`
+	Alt         string    `json:"alt" gorm:"type:text"`
+	Status      string    `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	Variants []ProductImageVariant `json:"variants,omitempty" gorm:"foreignKey:ImageID;constraint:OnDelete:CASCADE"`
+}
+
+// ProductImageVariant is a resized rendition of a ProductImage generated for one size preset
+type ProductImageVariant struct {
+	ID          int       `json:"id" gorm:"primaryKey"`
+	ImageID     int       `json:"image_id" gorm:"not null;uniqueIndex:idx_image_variant_name"`
+	Name        string    `json:"name" gorm:"type:varchar(50);not null;uniqueIndex:idx_image_variant_name"`
+	StorageKey  string    `json:"-" gorm:"not null;uniqueIndex"`
+	ContentType string    `json:"content_type" gorm:"not null"`
+	Size        int64     `json:"size" gorm:"not null"`
+	Width       int       `json:"width" gorm:"not null"`
+	Height      int       `json:"height" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+type UpdateImageRequest struct {
+	Position  *int   `json:"position,omitempty" validate:"omitempty,gte=0"`
+	IsPrimary *bool  `json:"is_primary,omitempty"`
+	Alt       string `json:"alt,omitempty" validate:"max=500"`
+}
+
+type ImageResponse struct {
+	ID          int       `json:"id"`
+	URL         string    `json:"url" example:"/media/products/1/3f2a.jpg"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Position    int       `json:"position"`
+	IsPrimary   bool      `json:"is_primary"`
+	Alt         string    `json:"alt"`
+	Status      string    `json:"status" example:"ready"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	Variants []ImageVariantResponse `json:"variants"`
+}
+
+type ImageVariantResponse struct {
+	Name        string `json:"name" example:"thumb"`
+	URL         string `json:"url" example:"/media/products/1/variants/9c1e.jpg"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
+
+// RegenerateImagesResponse reports how many images were queued for variant regeneration
+type RegenerateImagesResponse struct {
+	Queued int64 `json:"queued"`
+}
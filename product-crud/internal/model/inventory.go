@@ -0,0 +1,85 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	StockReasonReceipt    = "receipt"
+	StockReasonSale       = "sale"
+	StockReasonCorrection = "correction"
+)
+
+const (
+	ReservationActive    = "active"
+	ReservationCommitted = "committed"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired"
+)
+
+// StockLevel holds the on-hand and reserved quantities of a product. The check
+// constraints back up the repository's locking so stock can never go negative.
+type StockLevel struct {
+	ProductID int       `json:"product_id" gorm:"primaryKey;autoIncrement:false"`
+	Product   *Product  `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	OnHand    int       `json:"on_hand" gorm:"not null;default:0;check:chk_stock_on_hand,on_hand >= 0"`
+	Reserved  int       `json:"reserved" gorm:"not null;default:0;check:chk_stock_reserved,reserved >= 0 AND reserved <= on_hand"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// StockMovement is an entry of the append-only stock ledger
+type StockMovement struct {
+	ID          int       `json:"id" gorm:"primaryKey"`
+	ProductID   int       `json:"product_id" gorm:"not null;index"`
+	Product     *Product  `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Delta       int       `json:"delta" gorm:"not null"`
+	Reason      string    `json:"reason" gorm:"not null"`
+	Reference   string    `json:"reference,omitempty"`
+	OnHandAfter int       `json:"on_hand_after" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+// Reservation holds stock for a client until it is committed as a sale,
+// released, or expires
+type Reservation struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:uuid"`
+	ProductID int       `json:"product_id" gorm:"not null;index"`
+	Product   *Product  `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Quantity  int       `json:"quantity" gorm:"not null"`
+	Status    string    `json:"status" gorm:"not null;index:idx_reservations_status_expiry"`
+	Reference string    `json:"reference,omitempty"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index:idx_reservations_status_expiry"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type AdjustStockRequest struct {
+	// Delta is added to the on-hand quantity; negative values remove stock
+	Delta     int    `json:"delta" validate:"required"`
+	Reason    string `json:"reason" validate:"required,oneof=receipt sale correction"`
+	Reference string `json:"reference,omitempty" validate:"max=255"`
+}
+
+type CreateReservationRequest struct {
+	Quantity   int    `json:"quantity" validate:"gt=0"`
+	TTLSeconds int    `json:"ttl_seconds,omitempty" validate:"omitempty,gt=0,max=86400"`
+	Reference  string `json:"reference,omitempty" validate:"max=255"`
+}
+
+type StockResponse struct {
+	ProductID int       `json:"product_id"`
+	OnHand    int       `json:"on_hand"`
+	Reserved  int       `json:"reserved"`
+	Available int       `json:"available"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ReservationResponse struct {
+	ID        string    `json:"id"`
+	ProductID int       `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+	Status    string    `json:"status"`
+	Reference string    `json:"reference,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
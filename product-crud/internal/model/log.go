@@ -0,0 +1,6 @@
+package model
+
+// LogLevel is the level of the application logger: debug, info, warn or error
+type LogLevel struct {
+	Level string `json:"level" example:"debug" validate:"required"`
+}
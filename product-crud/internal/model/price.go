@@ -0,0 +1,62 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PriceHistory is one validity interval of a product's default price. Intervals may
+// overlap: at any instant the covering entry with the latest EffectiveFrom wins, so a
+// temporary price reverts to whatever was in effect before once EffectiveTo passes.
+type PriceHistory struct {
+	ID            int             `json:"id" gorm:"primaryKey"`
+	ProductID     int             `json:"product_id" gorm:"not null;index:idx_price_history_product_from"`
+	Amount        decimal.Decimal `json:"amount" gorm:"type:numeric(19,4);not null"`
+	Currency      string          `json:"currency" gorm:"type:char(3);not null"`
+	EffectiveFrom time.Time       `json:"effective_from" gorm:"not null;index:idx_price_history_product_from"`
+	EffectiveTo   *time.Time      `json:"effective_to"`
+	// AppliedAt and EndedAt are set once the scheduler has processed the start and end of the interval
+	AppliedAt *time.Time `json:"applied_at" gorm:"index"`
+	EndedAt   *time.Time `json:"ended_at" gorm:"index"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (PriceHistory) TableName() string {
+	return "price_history"
+}
+
+type SchedulePriceRequest struct {
+	Price decimal.Decimal `json:"price" swaggertype:"string" example:"17.99" validate:"positive,currencyscale=Currency"`
+	// Currency defaults to the product's current currency
+	Currency      string     `json:"currency,omitempty" example:"USD" validate:"omitempty,iso4217"`
+	EffectiveFrom time.Time  `json:"effective_from" example:"2026-11-27T00:00:00Z" validate:"required"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty" example:"2026-11-30T00:00:00Z" validate:"omitempty,gtfield=EffectiveFrom"`
+}
+
+type PriceHistoryResponse struct {
+	ID            int             `json:"id"`
+	Price         decimal.Decimal `json:"price" swaggertype:"string" example:"17.99"`
+	Currency      string          `json:"currency" example:"USD"`
+	EffectiveFrom time.Time       `json:"effective_from"`
+	EffectiveTo   *time.Time      `json:"effective_to"`
+	Status        string          `json:"status" example:"scheduled"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// PriceAtResponse is the price of a product at a point in time
+type PriceAtResponse struct {
+	ProductID int             `json:"product_id"`
+	At        time.Time       `json:"at"`
+	Price     decimal.Decimal `json:"price" swaggertype:"string" example:"19.99"`
+	Currency  string          `json:"currency" example:"USD"`
+	// EntryID is the price history entry in effect at that time
+	EntryID int `json:"entry_id"`
+}
+
+// Price history statuses derived from the interval and the scheduler's progress
+const (
+	PriceScheduled = "scheduled"
+	PriceActive    = "active"
+	PriceEnded     = "ended"
+)
@@ -1,35 +1,79 @@
 package model
 
 import (
+	"product-crud/pkg/money"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type Product struct {
-	ID          int       `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name" binding:"required" gorm:"not null"`
-	Description string    `json:"description" gorm:"type:text"`
-	Price       float64   `json:"price" binding:"required" gorm:"not null"`
-	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID          int              `json:"id" gorm:"primaryKey"`
+	Name        string           `json:"name" binding:"required" gorm:"not null"`
+	Description string           `json:"description" gorm:"type:text"`
+	Price       decimal.Decimal  `json:"price" gorm:"type:numeric(19,4);not null"`
+	Currency    string           `json:"currency" gorm:"type:char(3);not null;default:USD"`
+	Prices      []ProductPrice   `json:"prices" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	Categories  []Category       `json:"categories" gorm:"many2many:product_categories;constraint:OnDelete:CASCADE"`
+	Tags        []ProductTag     `json:"tags" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	Options     []ProductOption  `json:"options" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	Variants    []ProductVariant `json:"variants" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	Images      []ProductImage   `json:"images" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	PriceEvents []PriceHistory   `json:"-" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time        `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time        `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// ProductPrice is a product's price in a currency other than its default one
+type ProductPrice struct {
+	ID        int             `json:"-" gorm:"primaryKey"`
+	ProductID int             `json:"-" gorm:"not null;uniqueIndex:idx_product_prices_product_currency"`
+	Currency  string          `json:"currency" gorm:"type:char(3);not null;uniqueIndex:idx_product_prices_product_currency"`
+	Amount    decimal.Decimal `json:"amount" gorm:"type:numeric(19,4);not null"`
 }
 
 type CreateProductRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" binding:"required"`
+	Name        string                 `json:"name" validate:"required,notblank,max=255"`
+	Description string                 `json:"description" validate:"max=5000"`
+	Price       decimal.Decimal        `json:"price" swaggertype:"string" example:"19.99" validate:"positive,currencyscale=Currency"`
+	Currency    string                 `json:"currency,omitempty" example:"USD" validate:"omitempty,iso4217"`
+	Prices      []money.Money          `json:"prices,omitempty" validate:"omitempty,unique=Currency,dive"`
+	CategoryIDs []int                  `json:"category_ids,omitempty" validate:"omitempty,max=50,unique,dive,gt=0"`
+	Tags        []string               `json:"tags,omitempty" validate:"omitempty,max=50,dive,notblank,max=50"`
+	Options     []ProductOptionRequest `json:"options,omitempty" validate:"omitempty,max=10,unique=Name,dive"`
 }
 
 type UpdateProductRequest struct {
-	Name        string  `json:"name,omitempty"`
-	Description string  `json:"description,omitempty"`
-	Price       float64 `json:"price,omitempty"`
+	Name        string          `json:"name,omitempty" validate:"omitempty,notblank,max=255"`
+	Description string          `json:"description,omitempty" validate:"max=5000"`
+	Price       decimal.Decimal `json:"price,omitempty" swaggertype:"string" example:"19.99" validate:"omitempty,positive"`
+	Currency    string          `json:"currency,omitempty" example:"USD" validate:"omitempty,iso4217"`
+	Prices      []money.Money   `json:"prices,omitempty" validate:"omitempty,unique=Currency,dive"`
+	// CategoryIDs and Tags replace the current sets when present; an empty list clears them
+	CategoryIDs []int    `json:"category_ids,omitempty" validate:"omitempty,max=50,unique,dive,gt=0"`
+	Tags        []string `json:"tags,omitempty" validate:"omitempty,max=50,dive,notblank,max=50"`
+	// Options replaces the option definitions; values still used by variants cannot be removed
+	Options []ProductOptionRequest `json:"options,omitempty" validate:"omitempty,max=10,unique=Name,dive"`
 }
 
 type ProductResponse struct {
-	ID          int       `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int                     `json:"id"`
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	Price       decimal.Decimal         `json:"price" swaggertype:"string" example:"19.99"`
+	Currency    string                  `json:"currency" example:"USD"`
+	Prices      []money.Money           `json:"prices"`
+	Categories  []CategorySummary       `json:"categories"`
+	Tags        []string                `json:"tags"`
+	Options     []ProductOptionResponse `json:"options"`
+	Variants    []VariantResponse       `json:"variants"`
+	Images      []ImageResponse         `json:"images"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+}
+
+// CacheVersion orders cached copies of a product so that a snapshot read before an
+// update can never replace the updated one
+func (p *ProductResponse) CacheVersion() int64 {
+	return p.UpdatedAt.UnixMicro()
 }
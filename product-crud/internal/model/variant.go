@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ProductOption defines a dimension along which a product varies, e.g. size or color
+type ProductOption struct {
+	ID        int      `json:"-" gorm:"primaryKey"`
+	ProductID int      `json:"-" gorm:"not null;uniqueIndex:idx_product_options_product_name"`
+	Name      string   `json:"name" gorm:"not null;uniqueIndex:idx_product_options_product_name"`
+	Values    []string `json:"values" gorm:"type:jsonb;serializer:json;not null"`
+	Position  int      `json:"-" gorm:"not null;default:0"`
+}
+
+// ProductVariant is a purchasable combination of option values with its own SKU.
+// A null Price means the variant sells at the parent product's price.
+type ProductVariant struct {
+	ID           int                 `json:"id" gorm:"primaryKey"`
+	ProductID    int                 `json:"product_id" gorm:"not null;index"`
+	SKU          string              `json:"sku" gorm:"column:sku;not null;uniqueIndex"`
+	Price        decimal.NullDecimal `json:"price" gorm:"type:numeric(19,4)"`
+	OptionValues map[string]string   `json:"option_values" gorm:"type:jsonb;serializer:json;not null"`
+	Attributes   map[string]string   `json:"attributes" gorm:"type:jsonb;serializer:json;not null"`
+	CreatedAt    time.Time           `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time           `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type ProductOptionRequest struct {
+	Name   string   `json:"name" example:"size" validate:"required,notblank,max=50"`
+	Values []string `json:"values" example:"S,M,L" validate:"required,min=1,max=100,unique,dive,notblank,max=100"`
+}
+
+type ProductOptionResponse struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// VariantRequest is used both to create a variant and to replace an existing one
+type VariantRequest struct {
+	SKU string `json:"sku" example:"TSHIRT-RED-M" validate:"required,sku,max=64"`
+	// Price overrides the product's default-currency price when set
+	Price        *decimal.Decimal  `json:"price,omitempty" swaggertype:"string" example:"21.50" validate:"omitempty,positive"`
+	OptionValues map[string]string `json:"option_values" validate:"omitempty,max=10,dive,keys,notblank,max=50,endkeys,notblank,max=100"`
+	Attributes   map[string]string `json:"attributes,omitempty" validate:"omitempty,max=50,dive,keys,notblank,max=50,endkeys,max=500"`
+}
+
+type VariantResponse struct {
+	ID        int    `json:"id"`
+	ProductID int    `json:"product_id"`
+	SKU       string `json:"sku"`
+	// Price is the resolved price: the override if any, otherwise the product's price
+	Price         decimal.Decimal   `json:"price" swaggertype:"string" example:"21.50"`
+	Currency      string            `json:"currency" example:"USD"`
+	PriceOverride bool              `json:"price_override"`
+	OptionValues  map[string]string `json:"option_values"`
+	Attributes    map[string]string `json:"attributes"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// SKULookupResponse is a product together with the variant a SKU resolved to
+type SKULookupResponse struct {
+	Product *ProductResponse `json:"product"`
+	Variant *VariantResponse `json:"variant"`
+}
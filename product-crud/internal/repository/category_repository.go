@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"product-crud/internal/model"
+	"product-crud/pkg/db"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrCategoryHasChildren = errors.New("category has child categories")
+	ErrCategoryCycle       = errors.New("category cannot be moved under itself or its descendants")
+	ErrParentNotFound      = errors.New("parent category not found")
+)
+
+type CategoryRepository struct {
+	db *gorm.DB
+}
+
+func NewCategoryRepository(db *gorm.DB) *CategoryRepository {
+	return &CategoryRepository{
+		db: db,
+	}
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, category *model.Category) (int, error) {
+	now := time.Now()
+	category.CreatedAt = now
+	category.UpdatedAt = now
+
+	err := db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		parentPath := "/"
+		if category.ParentID != nil {
+			parent, err := findParent(tx, *category.ParentID)
+			if err != nil {
+				return err
+			}
+			parentPath = parent.Path
+		}
+
+		// The path embeds the generated ID, so it is written in a second step
+		category.Path = parentPath
+		if err := tx.Create(category).Error; err != nil {
+			return err
+		}
+
+		category.Path = fmt.Sprintf("%s%d/", parentPath, category.ID)
+		return tx.Model(category).Update("path", category.Path).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return category.ID, nil
+}
+
+func (r *CategoryRepository) GetByID(ctx context.Context, id int) (*model.Category, error) {
+	category := &model.Category{}
+	result := r.db.WithContext(ctx).First(category, id)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return category, nil
+}
+
+func (r *CategoryRepository) GetBySlug(ctx context.Context, slug string) (*model.Category, error) {
+	category := &model.Category{}
+	result := r.db.WithContext(ctx).Where("slug = ?", slug).First(category)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return category, nil
+}
+
+func (r *CategoryRepository) GetAll(ctx context.Context) ([]*model.Category, error) {
+	var categories []*model.Category
+	result := r.db.WithContext(ctx).Order("path").Find(&categories)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return categories, nil
+}
+
+// FindByIDs returns the categories among ids that exist
+func (r *CategoryRepository) FindByIDs(ctx context.Context, ids []int) ([]model.Category, error) {
+	categories := []model.Category{}
+	if len(ids) == 0 {
+		return categories, nil
+	}
+
+	result := r.db.WithContext(ctx).Where("id IN ?", ids).Order("path").Find(&categories)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return categories, nil
+}
+
+// Update writes the category's name and slug and, when its parent changed,
+// rewrites the materialized path of the whole subtree. The category and its new parent
+// are locked before the cycle check, so two categories moved under each other at the
+// same time cannot both pass it; moves that would close a longer cycle collide on the
+// subtree rewrite instead and are retried as deadlocks.
+func (r *CategoryRepository) Update(ctx context.Context, id int, category *model.Category) error {
+	category.UpdatedAt = time.Now()
+
+	return db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		ids := []int{id}
+		if category.ParentID != nil {
+			ids = append(ids, *category.ParentID)
+		}
+		locked, err := lockCategories(tx, ids)
+		if err != nil {
+			return err
+		}
+
+		current, ok := locked[id]
+		if !ok {
+			return gorm.ErrRecordNotFound
+		}
+
+		newPath := current.Path
+		if !sameParent(current.ParentID, category.ParentID) {
+			parentPath := "/"
+			if category.ParentID != nil {
+				parent, ok := locked[*category.ParentID]
+				if !ok {
+					return ErrParentNotFound
+				}
+				if strings.HasPrefix(parent.Path, current.Path) {
+					return ErrCategoryCycle
+				}
+				parentPath = parent.Path
+			}
+			newPath = fmt.Sprintf("%s%d/", parentPath, id)
+		}
+
+		result := tx.Model(&model.Category{ID: id}).Updates(map[string]interface{}{
+			"name":       category.Name,
+			"slug":       category.Slug,
+			"parent_id":  category.ParentID,
+			"path":       newPath,
+			"updated_at": category.UpdatedAt,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if newPath != current.Path {
+			result = tx.Exec(
+				"UPDATE categories SET path = ? || substr(path, ?) WHERE path LIKE ? AND id <> ?",
+				newPath, len(current.Path)+1, current.Path+"%", id,
+			)
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+
+		category.Path = newPath
+		return nil
+	})
+}
+
+// Delete removes a leaf category together with its product memberships, failing with
+// ErrCategoryNotFound if there is no such category
+func (r *CategoryRepository) Delete(ctx context.Context, id int) error {
+	return db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		var children int64
+		if err := tx.Model(&model.Category{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+			return err
+		}
+		if children > 0 {
+			return ErrCategoryHasChildren
+		}
+
+		if err := tx.Exec("DELETE FROM product_categories WHERE category_id = ?", id).Error; err != nil {
+			return err
+		}
+
+		result := tx.Delete(&model.Category{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCategoryNotFound
+		}
+		return nil
+	})
+}
+
+// lockCategories locks the categories among ids FOR UPDATE, in ID order so that
+// concurrent callers queue up rather than deadlock, and returns them by ID
+func lockCategories(tx *gorm.DB, ids []int) (map[int]*model.Category, error) {
+	var categories []*model.Category
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", ids).Order("id").Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int]*model.Category, len(categories))
+	for _, category := range categories {
+		result[category.ID] = category
+	}
+	return result, nil
+}
+
+func findParent(tx *gorm.DB, id int) (*model.Category, error) {
+	category := &model.Category{}
+	if err := tx.First(category, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrParentNotFound
+		}
+		return nil, err
+	}
+	return category, nil
+}
+
+func sameParent(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
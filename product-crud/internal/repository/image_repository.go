@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"product-crud/internal/model"
+	"product-crud/pkg/db"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrImageNotFound = errors.New("image not found")
+
+type ImageRepository struct {
+	db *gorm.DB
+}
+
+func NewImageRepository(db *gorm.DB) *ImageRepository {
+	return &ImageRepository{
+		db: db,
+	}
+}
+
+// Create stores the image metadata. The first image of a product always becomes
+// its primary image, and a new primary image demotes the previous one.
+func (r *ImageRepository) Create(ctx context.Context, image *model.ProductImage) (int, error) {
+	now := time.Now()
+	image.CreatedAt = now
+	image.UpdatedAt = now
+
+	err := db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.ProductImage{}).Where("product_id = ?", image.ProductID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count == 0 {
+			image.IsPrimary = true
+		} else if image.IsPrimary {
+			if err := clearPrimary(tx, image.ProductID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(image).Error; err != nil {
+			return err
+		}
+		return touchProduct(tx, "id = ?", image.ProductID)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return image.ID, nil
+}
+
+// GetByID returns the image only if it belongs to the given product
+func (r *ImageRepository) GetByID(ctx context.Context, productID, id int) (*model.ProductImage, error) {
+	image := &model.ProductImage{}
+	result := withVariants(r.db.WithContext(ctx)).Where("product_id = ?", productID).First(image, id)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return image, nil
+}
+
+func (r *ImageRepository) GetByProduct(ctx context.Context, productID int) ([]*model.ProductImage, error) {
+	var images []*model.ProductImage
+	result := withVariants(r.db.WithContext(ctx)).Where("product_id = ?", productID).Order("position, id").Find(&images)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return images, nil
+}
+
+// MaxPosition returns the highest position used by the product's images, or -1 if it has none
+func (r *ImageRepository) MaxPosition(ctx context.Context, productID int) (int, error) {
+	var position *int
+	result := r.db.WithContext(ctx).Model(&model.ProductImage{}).Where("product_id = ?", productID).Select("MAX(position)").Scan(&position)
+
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if position == nil {
+		return -1, nil
+	}
+	return *position, nil
+}
+
+func (r *ImageRepository) Update(ctx context.Context, id int, image *model.ProductImage) error {
+	image.UpdatedAt = time.Now()
+
+	return db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		if image.IsPrimary {
+			if err := clearPrimary(tx, image.ProductID); err != nil {
+				return err
+			}
+		}
+
+		err := tx.Model(&model.ProductImage{ID: id}).Updates(map[string]interface{}{
+			"position":   image.Position,
+			"is_primary": image.IsPrimary,
+			"alt":        image.Alt,
+			"updated_at": image.UpdatedAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return touchProduct(tx, "id = ?", image.ProductID)
+	})
+}
+
+// Delete removes an image and promotes the next one when the primary image is deleted
+func (r *ImageRepository) Delete(ctx context.Context, image *model.ProductImage) error {
+	return db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.ProductImage{}, image.ID).Error; err != nil {
+			return err
+		}
+
+		if err := touchProduct(tx, "id = ?", image.ProductID); err != nil {
+			return err
+		}
+
+		if !image.IsPrimary {
+			return nil
+		}
+
+		next := &model.ProductImage{}
+		result := tx.Where("product_id = ?", image.ProductID).Order("position, id").Limit(1).Find(next)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		return tx.Model(next).Update("is_primary", true).Error
+	})
+}
+
+// ClaimPending locks up to limit images waiting for variants and marks them as processing.
+// Images stuck in processing since before staleBefore, e.g. after a crash, are claimed again.
+func (r *ImageRepository) ClaimPending(ctx context.Context, staleBefore time.Time, limit int) ([]*model.ProductImage, error) {
+	var images []*model.ProductImage
+
+	err := db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND updated_at < ?)", model.ImageStatusPending, model.ImageStatusProcessing, staleBefore).
+			Order("id").
+			Limit(limit).
+			Find(&images)
+		if result.Error != nil || len(images) == 0 {
+			return result.Error
+		}
+
+		ids := make([]int, 0, len(images))
+		for _, image := range images {
+			ids = append(ids, image.ID)
+			image.Status = model.ImageStatusProcessing
+		}
+
+		return tx.Model(&model.ProductImage{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":     model.ImageStatusProcessing,
+			"updated_at": time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
+
+// ReplaceVariants swaps the variants of an image for freshly generated ones and returns
+// the previous variants so their files can be removed. The image is only marked ready if
+// no regeneration was requested while it was being processed.
+func (r *ImageRepository) ReplaceVariants(ctx context.Context, imageID int, variants []model.ProductImageVariant) ([]model.ProductImageVariant, error) {
+	var old []model.ProductImageVariant
+
+	err := db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		image := &model.ProductImage{}
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(image, imageID)
+		if result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return ErrImageNotFound
+			}
+			return result.Error
+		}
+
+		if err := tx.Where("image_id = ?", imageID).Find(&old).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("image_id = ?", imageID).Delete(&model.ProductImageVariant{}).Error; err != nil {
+			return err
+		}
+
+		if len(variants) > 0 {
+			for i := range variants {
+				variants[i].ImageID = imageID
+			}
+			if err := tx.Create(&variants).Error; err != nil {
+				return err
+			}
+		}
+
+		err := tx.Model(&model.ProductImage{}).
+			Where("id = ? AND status = ?", imageID, model.ImageStatusProcessing).
+			Update("status", model.ImageStatusReady).Error
+		if err != nil {
+			return err
+		}
+		return touchProduct(tx, "id = ?", image.ProductID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return old, nil
+}
+
+func (r *ImageRepository) SetStatus(ctx context.Context, id int, status string) error {
+	return db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		err := tx.Model(&model.ProductImage{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"status":     status,
+			"updated_at": time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+		return touchProduct(tx, "id = (?)", tx.Model(&model.ProductImage{}).Select("product_id").Where("id = ?", id))
+	})
+}
+
+// MarkPending queues the images of one product, or of all products when productID is zero,
+// for variant regeneration
+func (r *ImageRepository) MarkPending(ctx context.Context, productID int) (int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.ProductImage{})
+	if productID != 0 {
+		query = query.Where("product_id = ?", productID)
+	} else {
+		query = query.Where("1 = 1")
+	}
+
+	result := query.Updates(map[string]interface{}{
+		"status":     model.ImageStatusPending,
+		"updated_at": time.Now(),
+	})
+	return result.RowsAffected, result.Error
+}
+
+func withVariants(db *gorm.DB) *gorm.DB {
+	return db.Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("width, id") })
+}
+
+func clearPrimary(tx *gorm.DB, productID int) error {
+	return tx.Model(&model.ProductImage{}).
+		Where("product_id = ? AND is_primary", productID).
+		Update("is_primary", false).Error
+}
@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"product-crud/internal/model"
+	"product-crud/pkg/db"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientStock   = errors.New("insufficient stock")
+	ErrReservationNotFound = errors.New("reservation not found")
+	ErrReservationClosed   = errors.New("reservation is no longer active")
+	ErrReservationExpired  = errors.New("reservation has expired")
+)
+
+type InventoryRepository struct {
+	db *gorm.DB
+}
+
+func NewInventoryRepository(db *gorm.DB) *InventoryRepository {
+	return &InventoryRepository{
+		db: db,
+	}
+}
+
+// GetStock returns the product's stock level, or a zero level if stock was never recorded
+func (r *InventoryRepository) GetStock(ctx context.Context, productID int) (*model.StockLevel, error) {
+	stock := &model.StockLevel{}
+	result := r.db.WithContext(ctx).Where("product_id = ?", productID).First(stock)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return &model.StockLevel{ProductID: productID}, nil
+		}
+		return nil, result.Error
+	}
+	return stock, nil
+}
+
+// Adjust changes the on-hand quantity under a row lock and records the movement in the
+// ledger. Stock that is reserved cannot be removed.
+func (r *InventoryRepository) Adjust(ctx context.Context, productID, delta int, reason, reference string) (*model.StockLevel, error) {
+	var stock *model.StockLevel
+
+	err := db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		var err error
+		stock, err = lockStock(tx, productID)
+		if err != nil {
+			return err
+		}
+
+		if stock.OnHand+delta < stock.Reserved {
+			return ErrInsufficientStock
+		}
+
+		stock.OnHand += delta
+		stock.UpdatedAt = time.Now()
+		if err := tx.Model(stock).Updates(map[string]interface{}{
+			"on_hand":    stock.OnHand,
+			"updated_at": stock.UpdatedAt,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&model.StockMovement{
+			ProductID:   productID,
+			Delta:       delta,
+			Reason:      reason,
+			Reference:   reference,
+			OnHandAfter: stock.OnHand,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stock, nil
+}
+
+// GetMovements returns the most recent ledger entries of a product, newest first
+func (r *InventoryRepository) GetMovements(ctx context.Context, productID, limit int) ([]*model.StockMovement, error) {
+	var movements []*model.StockMovement
+	result := r.db.WithContext(ctx).Where("product_id = ?", productID).Order("id DESC").Limit(limit).Find(&movements)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return movements, nil
+}
+
+// Reserve holds quantity units for the reservation. The conditional update only
+// succeeds while enough unreserved stock is on hand, so parallel reservations
+// cannot oversell.
+func (r *InventoryRepository) Reserve(ctx context.Context, reservation *model.Reservation) error {
+	return db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		result := tx.Model(&model.StockLevel{}).
+			Where("product_id = ? AND on_hand - reserved >= ?", reservation.ProductID, reservation.Quantity).
+			Updates(map[string]interface{}{
+				"reserved":   gorm.Expr("reserved + ?", reservation.Quantity),
+				"updated_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInsufficientStock
+		}
+
+		reservation.Status = model.ReservationActive
+		return tx.Create(reservation).Error
+	})
+}
+
+func (r *InventoryRepository) GetReservation(ctx context.Context, id string) (*model.Reservation, error) {
+	reservation := &model.Reservation{}
+	result := r.db.WithContext(ctx).Where("id = ?", id).First(reservation)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return reservation, nil
+}
+
+// Release returns the reserved units of an active reservation to available stock
+func (r *InventoryRepository) Release(ctx context.Context, id string) (*model.Reservation, error) {
+	return r.closeReservation(ctx, id, model.ReservationReleased)
+}
+
+// Commit turns an active reservation into a sale, removing its units from stock
+func (r *InventoryRepository) Commit(ctx context.Context, id string) (*model.Reservation, error) {
+	return r.closeReservation(ctx, id, model.ReservationCommitted)
+}
+
+// ReleaseExpired releases up to limit active reservations that expired before now and
+// returns how many were released. Rows locked by another sweeper are skipped.
+func (r *InventoryRepository) ReleaseExpired(ctx context.Context, now time.Time, limit int) (int, error) {
+	released := 0
+
+	err := db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		var expired []*model.Reservation
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND expires_at <= ?", model.ReservationActive, now).
+			Order("expires_at").
+			Limit(limit).
+			Find(&expired)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		for _, reservation := range expired {
+			if err := releaseStock(tx, reservation, model.ReservationExpired); err != nil {
+				return err
+			}
+			released++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return released, nil
+}
+
+// closeReservation closes an active reservation with status. A reservation committed
+// after it expired is released as expired instead, as the sweeper would have done, and
+// ErrReservationExpired is returned.
+func (r *InventoryRepository) closeReservation(ctx context.Context, id, status string) (*model.Reservation, error) {
+	reservation := &model.Reservation{}
+	expired := false
+
+	err := db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		expired = false
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(reservation)
+		if result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return ErrReservationNotFound
+			}
+			return result.Error
+		}
+
+		if reservation.Status != model.ReservationActive {
+			return ErrReservationClosed
+		}
+
+		if status == model.ReservationCommitted && !reservation.ExpiresAt.After(time.Now()) {
+			expired = true
+			return releaseStock(tx, reservation, model.ReservationExpired)
+		}
+
+		return releaseStock(tx, reservation, status)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if expired {
+		return nil, ErrReservationExpired
+	}
+
+	return reservation, nil
+}
+
+// releaseStock gives back a locked reservation's units and marks it with status.
+// Committed reservations also leave the on-hand quantity and are written to the ledger.
+func releaseStock(tx *gorm.DB, reservation *model.Reservation, status string) error {
+	stock, err := lockStock(tx, reservation.ProductID)
+	if err != nil {
+		return err
+	}
+
+	stock.Reserved -= reservation.Quantity
+	if status == model.ReservationCommitted {
+		stock.OnHand -= reservation.Quantity
+	}
+	stock.UpdatedAt = time.Now()
+
+	if err := tx.Model(stock).Updates(map[string]interface{}{
+		"on_hand":    stock.OnHand,
+		"reserved":   stock.Reserved,
+		"updated_at": stock.UpdatedAt,
+	}).Error; err != nil {
+		return err
+	}
+
+	if status == model.ReservationCommitted {
+		err := tx.Create(&model.StockMovement{
+			ProductID:   reservation.ProductID,
+			Delta:       -reservation.Quantity,
+			Reason:      model.StockReasonSale,
+			Reference:   "reservation:" + reservation.ID,
+			OnHandAfter: stock.OnHand,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	reservation.Status = status
+	reservation.UpdatedAt = time.Now()
+	return tx.Model(reservation).Updates(map[string]interface{}{
+		"status":     reservation.Status,
+		"updated_at": reservation.UpdatedAt,
+	}).Error
+}
+
+// lockStock returns the product's stock row locked for update, creating it first if needed
+func lockStock(tx *gorm.DB, productID int) (*model.StockLevel, error) {
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.StockLevel{ProductID: productID, UpdatedAt: time.Now()}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stock := &model.StockLevel{}
+	result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("product_id = ?", productID).First(stock)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return stock, nil
+}
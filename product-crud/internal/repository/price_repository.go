@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"product-crud/internal/model"
+	"product-crud/pkg/db"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPriceNotFound       = errors.New("price not found")
+	ErrPriceAlreadyApplied = errors.New("price has already taken effect")
+)
+
+type PriceRepository struct {
+	db *gorm.DB
+}
+
+func NewPriceRepository(db *gorm.DB) *PriceRepository {
+	return &PriceRepository{
+		db: db,
+	}
+}
+
+// Schedule records a future price change
+func (r *PriceRepository) Schedule(ctx context.Context, entry *model.PriceHistory) error {
+	entry.CreatedAt = time.Now()
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+// GetHistory returns every price interval of a product, latest first
+func (r *PriceRepository) GetHistory(ctx context.Context, productID int) ([]*model.PriceHistory, error) {
+	var entries []*model.PriceHistory
+	result := r.db.WithContext(ctx).Where("product_id = ?", productID).Order("effective_from DESC, id DESC").Find(&entries)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return entries, nil
+}
+
+// PriceAt returns the entry in effect at the given time, or nil if the product had no price then
+func (r *PriceRepository) PriceAt(ctx context.Context, productID int, at time.Time) (*model.PriceHistory, error) {
+	return effectiveAt(r.db.WithContext(ctx), productID, at)
+}
+
+// Cancel deletes a scheduled price that has not taken effect yet
+func (r *PriceRepository) Cancel(ctx context.Context, productID, id int) error {
+	return db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		entry := &model.PriceHistory{}
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("product_id = ?", productID).First(entry, id)
+		if result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return ErrPriceNotFound
+			}
+			return result.Error
+		}
+
+		if entry.AppliedAt != nil {
+			return ErrPriceAlreadyApplied
+		}
+
+		return tx.Delete(entry).Error
+	})
+}
+
+// DueProducts returns up to limit products with an interval that started or ended by now
+// but has not been processed yet
+func (r *PriceRepository) DueProducts(ctx context.Context, now time.Time, limit int) ([]int, error) {
+	var ids []int
+	result := r.db.WithContext(ctx).Model(&model.PriceHistory{}).
+		Where("(applied_at IS NULL AND effective_from <= ?) OR (ended_at IS NULL AND effective_to <= ?)", now, now).
+		Distinct("product_id").
+		Order("product_id").
+		Limit(limit).
+		Pluck("product_id", &ids)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return ids, nil
+}
+
+// ApplyDue sets the product's price to the entry in effect at now and marks the started and
+// ended intervals as processed. It reports whether the product's price changed.
+func (r *PriceRepository) ApplyDue(ctx context.Context, productID int, now time.Time) (bool, error) {
+	changed := false
+
+	err := db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		product := &model.Product{}
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Select("id", "price", "currency").
+			Where("id = ?", productID).
+			Limit(1).
+			Find(product)
+		if result.Error != nil || result.RowsAffected == 0 {
+			// Deleted, or being updated by someone else; the next run picks it up
+			return result.Error
+		}
+
+		var started []*model.PriceHistory
+		err := tx.Where("product_id = ? AND applied_at IS NULL AND effective_from <= ?", productID, now).
+			Order("effective_from, id").
+			Find(&started).Error
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range started {
+			if entry.EffectiveTo == nil {
+				if err := closeOpenPrices(tx, productID, entry.ID, entry.EffectiveFrom); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := tx.Model(&model.PriceHistory{}).
+			Where("product_id = ? AND applied_at IS NULL AND effective_from <= ?", productID, now).
+			Update("applied_at", now).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.PriceHistory{}).
+			Where("product_id = ? AND ended_at IS NULL AND effective_to <= ?", productID, now).
+			Update("ended_at", now).Error; err != nil {
+			return err
+		}
+
+		current, err := effectiveAt(tx, productID, now)
+		if err != nil || current == nil {
+			return err
+		}
+
+		if current.Amount.Equal(product.Price) && current.Currency == product.Currency {
+			return nil
+		}
+
+		if err := setProductPrice(tx, productID, current.Amount, current.Currency); err != nil {
+			return err
+		}
+		changed = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return changed, nil
+}
+
+func effectiveAt(db *gorm.DB, productID int, at time.Time) (*model.PriceHistory, error) {
+	entry := &model.PriceHistory{}
+	result := db.Where("product_id = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)", productID, at, at).
+		Order("effective_from DESC, id DESC").
+		Limit(1).
+		Find(entry)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return entry, nil
+}
+
+// recordPrice starts a new open-ended price interval at from, closing the open-ended
+// intervals it supersedes. Scheduled intervals starting later are left untouched.
+func recordPrice(tx *gorm.DB, product *model.Product, from time.Time) error {
+	entry := &model.PriceHistory{
+		ProductID:     product.ID,
+		Amount:        product.Price,
+		Currency:      product.Currency,
+		EffectiveFrom: from,
+		AppliedAt:     &from,
+		CreatedAt:     from,
+	}
+	if err := tx.Create(entry).Error; err != nil {
+		return err
+	}
+
+	return closeOpenPrices(tx, product.ID, entry.ID, from)
+}
+
+func closeOpenPrices(tx *gorm.DB, productID, exceptID int, at time.Time) error {
+	return tx.Model(&model.PriceHistory{}).
+		Where("product_id = ? AND id <> ? AND effective_to IS NULL AND effective_from <= ?", productID, exceptID, at).
+		Updates(map[string]interface{}{
+			"effective_to": at,
+			"ended_at":     at,
+		}).Error
+}
+
+// setProductPrice moves the product's default price, dropping an additional price in the
+// same currency so the currency is not listed twice. updated_at is bumped with touchProduct
+// rather than set to the scheduler's clock, which may predate a concurrent update.
+func setProductPrice(tx *gorm.DB, productID int, amount decimal.Decimal, currency string) error {
+	err := tx.Model(&model.Product{ID: productID}).UpdateColumns(map[string]interface{}{
+		"price":    amount,
+		"currency": currency,
+	}).Error
+	if err != nil {
+		return err
+	}
+	if err := touchProduct(tx, "id = ?", productID); err != nil {
+		return err
+	}
+
+	return tx.Where("product_id = ? AND currency = ?", productID, currency).Delete(&model.ProductPrice{}).Error
+}
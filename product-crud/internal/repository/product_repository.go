@@ -1,38 +1,50 @@
 package repository
 
 import (
+	"context"
 	"product-crud/internal/model"
+	"product-crud/pkg/db"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductRepository struct {
 	db *gorm.DB
+	// cluster routes GetByID and GetAll to the read replicas
+	cluster *db.Cluster
 }
 
-func NewProductRepository(db *gorm.DB) *ProductRepository {
+func NewProductRepository(cluster *db.Cluster) *ProductRepository {
 	return &ProductRepository{
-		db: db,
+		db:      cluster.Primary(),
+		cluster: cluster,
 	}
 }
 
-func (r *ProductRepository) Create(product *model.Product) (int, error) {
+func (r *ProductRepository) Create(ctx context.Context, product *model.Product) (int, error) {
 	now := time.Now()
 	product.CreatedAt = now
 	product.UpdatedAt = now
 
-	result := r.db.Create(product)
-	if result.Error != nil {
-		return 0, result.Error
+	err := db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		if err := tx.Omit("Categories.*").Create(product).Error; err != nil {
+			return err
+		}
+		return recordPrice(tx, product, now)
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	return product.ID, nil
 }
 
-func (r *ProductRepository) GetByID(id int) (*model.Product, error) {
+// GetByID reads from a replica unless ctx asks for the primary; see db.WithPrimary
+func (r *ProductRepository) GetByID(ctx context.Context, id int) (*model.Product, error) {
 	product := &model.Product{}
-	result := r.db.First(product, id)
+	result := withAssociations(r.cluster.Reader(ctx)).First(product, id)
 
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
@@ -43,9 +55,49 @@ func (r *ProductRepository) GetByID(id int) (*model.Product, error) {
 	return product, nil
 }
 
-func (r *ProductRepository) GetAll() ([]*model.Product, error) {
+// GetByIDs loads the products among ids that exist, with their associations, in one query
+func (r *ProductRepository) GetByIDs(ctx context.Context, ids []int) ([]*model.Product, error) {
 	var products []*model.Product
-	result := r.db.Order("id").Find(&products)
+	if len(ids) == 0 {
+		return products, nil
+	}
+
+	result := withAssociations(r.db.WithContext(ctx)).Where("id IN ?", ids).Order("id").Find(&products)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return products, nil
+}
+
+// GetIDs returns the IDs of the products created at or after since; a zero since returns all
+func (r *ProductRepository) GetIDs(ctx context.Context, since time.Time) ([]int, error) {
+	var ids []int
+	query := r.db.WithContext(ctx).Model(&model.Product{})
+	if !since.IsZero() {
+		query = query.Where("created_at >= ?", since)
+	}
+
+	if err := query.Order("id").Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *ProductRepository) Exists(ctx context.Context, id int) (bool, error) {
+	var count int64
+	result := r.db.WithContext(ctx).Model(&model.Product{}).Where("id = ?", id).Count(&count)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return count > 0, nil
+}
+
+// GetAll reads from a replica unless ctx asks for the primary; see db.WithPrimary
+func (r *ProductRepository) GetAll(ctx context.Context, filter model.ProductFilter) ([]*model.Product, error) {
+	var products []*model.Product
+	reads := r.cluster.Reader(ctx)
+	result := filterProducts(reads, withAssociations(reads), filter).Order("id").Find(&products)
 
 	if result.Error != nil {
 		return nil, result.Error
@@ -54,20 +106,189 @@ func (r *ProductRepository) GetAll() ([]*model.Product, error) {
 	return products, nil
 }
 
-func (r *ProductRepository) Update(id int, product *model.Product) error {
+// GetPage returns up to limit products matching filter with IDs above afterID, in ID
+// order. Like GetAll it reads from a replica unless ctx asks for the primary.
+func (r *ProductRepository) GetPage(ctx context.Context, filter model.ProductFilter, afterID, limit int) ([]*model.Product, error) {
+	var products []*model.Product
+	reads := r.cluster.Reader(ctx)
+	result := filterProducts(reads, withAssociations(reads), filter).
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&products)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return products, nil
+}
+
+// Count returns how many products match filter, reading like GetAll
+func (r *ProductRepository) Count(ctx context.Context, filter model.ProductFilter) (int64, error) {
+	var count int64
+	reads := r.cluster.Reader(ctx)
+	result := filterProducts(reads, reads.Model(&model.Product{}), filter).Count(&count)
+
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return count, nil
+}
+
+// filterProducts restricts query to the products matching filter, building the
+// subqueries on reads
+func filterProducts(reads, query *gorm.DB, filter model.ProductFilter) *gorm.DB {
+	if filter.CategoryID != 0 || filter.CategorySlug != "" {
+		// Match the category and every descendant through the materialized path
+		subtree := reads.Table("categories AS root").
+			Select("c.id").
+			Joins("JOIN categories AS c ON c.path LIKE root.path || '%'")
+		if filter.CategoryID != 0 {
+			subtree = subtree.Where("root.id = ?", filter.CategoryID)
+		} else {
+			subtree = subtree.Where("root.slug = ?", filter.CategorySlug)
+		}
+
+		query = query.Where("id IN (?)",
+			reads.Table("product_categories").Select("product_id").Where("category_id IN (?)", subtree))
+	}
+
+	if filter.Tag != "" {
+		query = query.Where("id IN (?)",
+			reads.Model(&model.ProductTag{}).Select("product_id").Where("tag = ?", filter.Tag))
+	}
+
+	return query
+}
+
+// Update writes the product's columns. Non-nil Prices, Categories, Tags and Options replace
+// the product's current associations in the same transaction. A changed price starts a
+// new price history interval.
+func (r *ProductRepository) Update(ctx context.Context, id int, product *model.Product) error {
 	product.UpdatedAt = time.Now()
 
-	result := r.db.Model(&model.Product{ID: id}).Updates(map[string]interface{}{
-		"name":        product.Name,
-		"description": product.Description,
-		"price":       product.Price,
-		"updated_at":  product.UpdatedAt,
+	return db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		current := &model.Product{}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "price", "currency").First(current, id).Error
+		if err != nil {
+			return err
+		}
+
+		if !current.Price.Equal(product.Price) || current.Currency != product.Currency {
+			if err := recordPrice(tx, product, product.UpdatedAt); err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&model.Product{ID: id}).Updates(map[string]interface{}{
+			"name":        product.Name,
+			"description": product.Description,
+			"price":       product.Price,
+			"currency":    product.Currency,
+			"updated_at":  product.UpdatedAt,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if product.Prices != nil {
+			if err := replacePrices(tx, id, product.Prices); err != nil {
+				return err
+			}
+		}
+
+		if product.Categories != nil {
+			err := tx.Model(&model.Product{ID: id}).Omit("Categories.*").Association("Categories").Replace(product.Categories)
+			if err != nil {
+				return err
+			}
+		}
+
+		if product.Tags != nil {
+			if err := replaceTags(tx, id, product.Tags); err != nil {
+				return err
+			}
+		}
+
+		if product.Options != nil {
+			if err := replaceOptions(tx, id, product.Options); err != nil {
+				return err
+			}
+		}
+
+		return nil
 	})
+}
 
+func (r *ProductRepository) Delete(ctx context.Context, id int) error {
+	result := r.db.WithContext(ctx).Delete(&model.Product{}, id)
 	return result.Error
 }
 
-func (r *ProductRepository) Delete(id int) error {
-	result := r.db.Delete(&model.Product{}, id)
-	return result.Error
+// touchProduct bumps the updated_at of the products matched by where, which versions their
+// cached copies, after a change to rows they own such as variants or images. It never goes
+// backwards, so the new version outranks every copy read before the change.
+func touchProduct(tx *gorm.DB, where string, args ...interface{}) error {
+	return tx.Model(&model.Product{}).Where(where, args...).
+		Update("updated_at", gorm.Expr("GREATEST(?, updated_at + interval '1 microsecond')", time.Now())).Error
+}
+
+func replacePrices(tx *gorm.DB, id int, prices []model.ProductPrice) error {
+	if err := tx.Where("product_id = ?", id).Delete(&model.ProductPrice{}).Error; err != nil {
+		return err
+	}
+
+	if len(prices) == 0 {
+		return nil
+	}
+
+	for i := range prices {
+		prices[i].ID = 0
+		prices[i].ProductID = id
+	}
+	return tx.Create(&prices).Error
+}
+
+func replaceTags(tx *gorm.DB, id int, tags []model.ProductTag) error {
+	if err := tx.Where("product_id = ?", id).Delete(&model.ProductTag{}).Error; err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	for i := range tags {
+		tags[i].ProductID = id
+	}
+	return tx.Create(&tags).Error
+}
+
+func replaceOptions(tx *gorm.DB, id int, options []model.ProductOption) error {
+	if err := tx.Where("product_id = ?", id).Delete(&model.ProductOption{}).Error; err != nil {
+		return err
+	}
+
+	if len(options) == 0 {
+		return nil
+	}
+
+	for i := range options {
+		options[i].ID = 0
+		options[i].ProductID = id
+	}
+	return tx.Create(&options).Error
+}
+
+func withAssociations(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Prices", func(db *gorm.DB) *gorm.DB { return db.Order("currency") }).
+		Preload("Categories", func(db *gorm.DB) *gorm.DB { return db.Order("path") }).
+		Preload("Tags", func(db *gorm.DB) *gorm.DB { return db.Order("tag") }).
+		Preload("Options", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Images", func(db *gorm.DB) *gorm.DB { return db.Order("position, id") }).
+		Preload("Images.Variants", func(db *gorm.DB) *gorm.DB { return db.Order("width, id") })
 }
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"product-crud/internal/model"
+	"product-crud/pkg/db"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrVariantNotFound = errors.New("variant not found")
+
+type VariantRepository struct {
+	db *gorm.DB
+}
+
+func NewVariantRepository(db *gorm.DB) *VariantRepository {
+	return &VariantRepository{
+		db: db,
+	}
+}
+
+func (r *VariantRepository) Create(ctx context.Context, variant *model.ProductVariant) (int, error) {
+	now := time.Now()
+	variant.CreatedAt = now
+	variant.UpdatedAt = now
+
+	err := db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		if err := tx.Create(variant).Error; err != nil {
+			return err
+		}
+		return touchProduct(tx, "id = ?", variant.ProductID)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return variant.ID, nil
+}
+
+// GetByID returns the variant only if it belongs to the given product
+func (r *VariantRepository) GetByID(ctx context.Context, productID, id int) (*model.ProductVariant, error) {
+	variant := &model.ProductVariant{}
+	result := r.db.WithContext(ctx).Where("product_id = ?", productID).First(variant, id)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return variant, nil
+}
+
+func (r *VariantRepository) GetBySKU(ctx context.Context, sku string) (*model.ProductVariant, error) {
+	variant := &model.ProductVariant{}
+	result := r.db.WithContext(ctx).Where("sku = ?", sku).First(variant)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return variant, nil
+}
+
+func (r *VariantRepository) GetByProduct(ctx context.Context, productID int) ([]*model.ProductVariant, error) {
+	var variants []*model.ProductVariant
+	result := r.db.WithContext(ctx).Where("product_id = ?", productID).Order("id").Find(&variants)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return variants, nil
+}
+
+func (r *VariantRepository) Update(ctx context.Context, id int, variant *model.ProductVariant) error {
+	variant.UpdatedAt = time.Now()
+
+	return db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		err := tx.Model(&model.ProductVariant{ID: id}).Select("sku", "price", "option_values", "attributes", "updated_at").Updates(variant).Error
+		if err != nil {
+			return err
+		}
+		return touchProduct(tx, "id = ?", variant.ProductID)
+	})
+}
+
+// Delete removes the variant only if it belongs to the given product, failing with
+// ErrVariantNotFound otherwise
+func (r *VariantRepository) Delete(ctx context.Context, productID, id int) error {
+	return db.Transaction(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		result := tx.Where("product_id = ?", productID).Delete(&model.ProductVariant{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVariantNotFound
+		}
+		return touchProduct(tx, "id = ?", productID)
+	})
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"product-crud/internal/model"
+	"product-crud/internal/validation"
+	"product-crud/pkg/cache"
+	"product-crud/pkg/logger"
+	"strings"
+)
+
+// maxWarmUpCount bounds how many products one warm-up may load
+const maxWarmUpCount = 10000
+
+// ErrCacheDisabled is returned by the cache administration calls when Redis is not configured
+var ErrCacheDisabled = errors.New("cache is not enabled")
+
+// CacheService lets operators inspect, flush and warm up the cache
+type CacheService struct {
+	cache    *cache.RedisCache
+	products *ProductService
+	logger   *logger.Logger
+}
+
+func NewCacheService(cache *cache.RedisCache, products *ProductService, logger *logger.Logger) *CacheService {
+	return &CacheService{
+		cache:    cache,
+		products: products,
+		logger:   logger,
+	}
+}
+
+// Breaker returns the state of the circuit breaker in front of Redis, or nil without a cache
+func (s *CacheService) Breaker() *cache.BreakerStats {
+	if s.cache == nil {
+		return nil
+	}
+
+	breaker := s.cache.Breaker()
+	return &breaker
+}
+
+func (s *CacheService) Stats(ctx context.Context) (*cache.Stats, error) {
+	if s.cache == nil {
+		return nil, ErrCacheDisabled
+	}
+
+	return s.cache.Stats(ctx)
+}
+
+// Inspect returns what is cached under key, or nil if nothing is
+func (s *CacheService) Inspect(ctx context.Context, key string) (*cache.KeyInfo, error) {
+	if s.cache == nil {
+		return nil, ErrCacheDisabled
+	}
+
+	if key == "" {
+		return nil, validation.Errors{{Field: "key", Code: "required", Message: "is required"}}
+	}
+
+	return s.cache.Inspect(ctx, key)
+}
+
+// Flush removes the keys of a namespace, or those matching a Redis glob pattern; exactly
+// one of the two must be given
+func (s *CacheService) Flush(ctx context.Context, namespace, pattern string) (*model.FlushCacheResponse, error) {
+	if s.cache == nil {
+		return nil, ErrCacheDisabled
+	}
+
+	switch {
+	case namespace != "" && pattern != "":
+		return nil, validation.Errors{{Field: "pattern", Code: "conflict", Message: "cannot be combined with namespace"}}
+	case namespace != "":
+		if strings.ContainsAny(namespace, `:*?[]\`) {
+			return nil, validation.Errors{{Field: "namespace", Code: "invalid", Message: "must not contain ':' or glob characters"}}
+		}
+		pattern = namespace + ":*"
+	case pattern == "":
+		return nil, validation.Errors{{Field: "namespace", Code: "required", Message: "namespace or pattern is required"}}
+	}
+
+	deleted, err := s.cache.Flush(ctx, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Ctx(ctx).Info("Flushed cache", logger.String("pattern", pattern), logger.Int64("deleted", deleted))
+	return &model.FlushCacheResponse{Pattern: pattern, Deleted: deleted}, nil
+}
+
+// WarmUp preloads the count most requested products
+func (s *CacheService) WarmUp(ctx context.Context, count int) (*model.WarmUpCacheResponse, error) {
+	if s.cache == nil {
+		return nil, ErrCacheDisabled
+	}
+
+	if count <= 0 || count > maxWarmUpCount {
+		return nil, validation.Errors{{Field: "count", Code: "out_of_range", Message: "must be between 1 and 10000"}}
+	}
+
+	cached, err := s.products.WarmUp(ctx, count)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.WarmUpCacheResponse{Requested: count, Cached: cached}, nil
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"product-crud/internal/model"
+	"product-crud/internal/repository"
+	"product-crud/internal/validation"
+	"product-crud/pkg/cache"
+	"product-crud/pkg/logger"
+	"strings"
+)
+
+type CategoryService struct {
+	repo   *repository.CategoryRepository
+	cache  *cache.RedisCache
+	logger *logger.Logger
+}
+
+func NewCategoryService(repo *repository.CategoryRepository, cache *cache.RedisCache, logger *logger.Logger) *CategoryService {
+	return &CategoryService{
+		repo:   repo,
+		cache:  cache,
+		logger: logger,
+	}
+}
+
+func (s *CategoryService) Create(ctx context.Context, req *model.CreateCategoryRequest) (*model.CategoryResponse, error) {
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.ensureSlugAvailable(ctx, req.Slug, 0); err != nil {
+		return nil, err
+	}
+
+	category := &model.Category{
+		Name:     strings.TrimSpace(req.Name),
+		Slug:     req.Slug,
+		ParentID: req.ParentID,
+	}
+
+	if _, err := s.repo.Create(ctx, category); err != nil {
+		return nil, categoryError(err)
+	}
+
+	return toCategoryResponse(category), nil
+}
+
+func (s *CategoryService) GetByID(ctx context.Context, id int) (*model.CategoryResponse, error) {
+	category, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if category == nil {
+		return nil, nil
+	}
+
+	return toCategoryResponse(category), nil
+}
+
+// GetByIDs returns the categories among ids that exist, keyed by ID
+func (s *CategoryService) GetByIDs(ctx context.Context, ids []int) (map[int]*model.CategoryResponse, error) {
+	categories, err := s.repo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int]*model.CategoryResponse, len(categories))
+	for i := range categories {
+		result[categories[i].ID] = toCategoryResponse(&categories[i])
+	}
+
+	return result, nil
+}
+
+func (s *CategoryService) GetAll(ctx context.Context) ([]*model.CategoryResponse, error) {
+	categories, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]*model.CategoryResponse, 0, len(categories))
+	for _, category := range categories {
+		response = append(response, toCategoryResponse(category))
+	}
+
+	return response, nil
+}
+
+func (s *CategoryService) Update(ctx context.Context, id int, req *model.UpdateCategoryRequest) (*model.CategoryResponse, error) {
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	category, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if category == nil {
+		return nil, nil
+	}
+
+	if req.Name != "" {
+		category.Name = strings.TrimSpace(req.Name)
+	}
+
+	if req.Slug != "" && req.Slug != category.Slug {
+		if err := s.ensureSlugAvailable(ctx, req.Slug, id); err != nil {
+			return nil, err
+		}
+		category.Slug = req.Slug
+	}
+
+	if req.ParentID != nil {
+		if *req.ParentID == 0 {
+			category.ParentID = nil
+		} else {
+			category.ParentID = req.ParentID
+		}
+	}
+
+	if err := s.repo.Update(ctx, id, category); err != nil {
+		return nil, categoryError(err)
+	}
+
+	// Product payloads embed category names and paths
+	invalidateProducts(ctx, s.cache, s.logger)
+
+	return toCategoryResponse(category), nil
+}
+
+func (s *CategoryService) Delete(ctx context.Context, id int) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	invalidateProducts(ctx, s.cache, s.logger)
+
+	return nil
+}
+
+func (s *CategoryService) ensureSlugAvailable(ctx context.Context, slug string, id int) error {
+	existing, err := s.repo.GetBySlug(ctx, slug)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil && existing.ID != id {
+		return validation.Errors{{
+			Field:   "slug",
+			Code:    "taken",
+			Message: "is already used by another category",
+		}}
+	}
+
+	return nil
+}
+
+// categoryError turns tree constraint violations into field errors on parent_id
+func categoryError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrParentNotFound):
+		return validation.Errors{{Field: "parent_id", Code: "not_found", Message: "does not reference an existing category"}}
+	case errors.Is(err, repository.ErrCategoryCycle):
+		return validation.Errors{{Field: "parent_id", Code: "cycle", Message: "must not be the category itself or one of its descendants"}}
+	default:
+		return err
+	}
+}
+
+func toCategoryResponse(category *model.Category) *model.CategoryResponse {
+	return &model.CategoryResponse{
+		ID:        category.ID,
+		ParentID:  category.ParentID,
+		Name:      category.Name,
+		Slug:      category.Slug,
+		Path:      category.Path,
+		CreatedAt: category.CreatedAt,
+		UpdatedAt: category.UpdatedAt,
+	}
+}
@@ -0,0 +1,410 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"product-crud/internal/model"
+	"product-crud/internal/repository"
+	"product-crud/internal/validation"
+	"product-crud/pkg/imaging"
+	"product-crud/pkg/logger"
+	"product-crud/pkg/storage"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MediaPathPrefix is the URL path under which stored media is served
+const MediaPathPrefix = "/media/"
+
+var (
+	ErrImageTooLarge        = errors.New("image exceeds the maximum upload size")
+	ErrUnsupportedImageType = errors.New("unsupported image type")
+)
+
+// variantStaleAfter is how long an image may stay in processing before another worker reclaims it
+const variantStaleAfter = 10 * time.Minute
+
+// imageExtensions maps the sniffed content types accepted for upload to file extensions
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// UploadImageOptions carries the optional form fields of an image upload
+type UploadImageOptions struct {
+	Position  *int
+	IsPrimary bool
+	Alt       string
+}
+
+type ImageService struct {
+	repo        *repository.ImageRepository
+	productRepo *repository.ProductRepository
+	products    *ProductService
+	blob        storage.Blob
+	logger      *logger.Logger
+	maxSize     int64
+	presets     []imaging.Preset
+	wake        chan struct{}
+}
+
+func NewImageService(repo *repository.ImageRepository, productRepo *repository.ProductRepository, products *ProductService, blob storage.Blob, logger *logger.Logger, maxSize int64, presets []imaging.Preset) *ImageService {
+	return &ImageService{
+		repo:        repo,
+		productRepo: productRepo,
+		products:    products,
+		blob:        blob,
+		logger:      logger,
+		maxSize:     maxSize,
+		presets:     presets,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// MaxSize returns the largest accepted upload in bytes
+func (s *ImageService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Upload sniffs, stores and records a product image, or returns nil if the product does not exist
+func (s *ImageService) Upload(ctx context.Context, productID int, r io.Reader, opts UploadImageOptions) (*model.ImageResponse, error) {
+	if len(opts.Alt) > 500 {
+		return nil, validation.Errors{{Field: "alt", Code: "too_long", Message: "must be at most 500 characters long"}}
+	}
+	if opts.Position != nil && *opts.Position < 0 {
+		return nil, validation.Errors{{Field: "position", Code: "out_of_range", Message: "must be at least 0"}}
+	}
+
+	exists, err := s.productRepo.Exists(ctx, productID)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, ErrImageTooLarge
+	}
+
+	// The declared Content-Type of the part is ignored; only the bytes decide
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, ErrUnsupportedImageType
+	}
+
+	img := &model.ProductImage{
+		ProductID:   productID,
+		StorageKey:  fmt.Sprintf("products/%d/%s%s", productID, uuid.New().String(), ext),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		IsPrimary:   opts.IsPrimary,
+		Alt:         opts.Alt,
+		Status:      model.ImageStatusPending,
+	}
+
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		img.Width = cfg.Width
+		img.Height = cfg.Height
+	}
+
+	if opts.Position != nil {
+		img.Position = *opts.Position
+	} else {
+		last, err := s.repo.MaxPosition(ctx, productID)
+		if err != nil {
+			return nil, err
+		}
+		img.Position = last + 1
+	}
+
+	if err := s.blob.Put(ctx, img.StorageKey, bytes.NewReader(data), img.Size, contentType); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.Create(ctx, img); err != nil {
+		if delErr := s.blob.Delete(ctx, img.StorageKey); delErr != nil {
+			s.logger.Ctx(ctx).Error("Failed to remove orphaned image", logger.String("key", img.StorageKey), logger.Err(delErr))
+		}
+		return nil, err
+	}
+
+	s.products.invalidateProduct(ctx, productID)
+	s.notifyWorker()
+
+	return toImageResponse(img), nil
+}
+
+// GetAll returns the images of a product, or nil if the product does not exist
+func (s *ImageService) GetAll(ctx context.Context, productID int) ([]*model.ImageResponse, error) {
+	exists, err := s.productRepo.Exists(ctx, productID)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	images, err := s.repo.GetByProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]*model.ImageResponse, 0, len(images))
+	for _, img := range images {
+		response = append(response, toImageResponse(img))
+	}
+
+	return response, nil
+}
+
+// Update changes the ordering, primary flag or alt text of an image, or returns nil if it does not exist
+func (s *ImageService) Update(ctx context.Context, productID, id int, req *model.UpdateImageRequest) (*model.ImageResponse, error) {
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	img, err := s.repo.GetByID(ctx, productID, id)
+	if err != nil || img == nil {
+		return nil, err
+	}
+
+	if req.Position != nil {
+		img.Position = *req.Position
+	}
+
+	// The primary image can only be replaced by promoting another one
+	if req.IsPrimary != nil && *req.IsPrimary {
+		img.IsPrimary = true
+	}
+
+	if req.Alt != "" {
+		img.Alt = req.Alt
+	}
+
+	if err := s.repo.Update(ctx, id, img); err != nil {
+		return nil, err
+	}
+
+	s.products.invalidateProduct(ctx, productID)
+
+	return toImageResponse(img), nil
+}
+
+// Delete removes an image's metadata, its stored file and the files of its variants
+func (s *ImageService) Delete(ctx context.Context, productID, id int) error {
+	img, err := s.repo.GetByID(ctx, productID, id)
+	if err != nil || img == nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, img); err != nil {
+		return err
+	}
+
+	keys := []string{img.StorageKey}
+	for _, variant := range img.Variants {
+		keys = append(keys, variant.StorageKey)
+	}
+	s.removeFiles(ctx, keys)
+
+	s.products.invalidateProduct(ctx, productID)
+
+	return nil
+}
+
+// OpenMedia opens a stored media file for serving
+func (s *ImageService) OpenMedia(ctx context.Context, key string) (io.ReadCloser, *storage.Object, error) {
+	return s.blob.Get(ctx, key)
+}
+
+// Regenerate queues the images of a product, or of every product when productID is zero,
+// for variant regeneration. It returns -1 if the product does not exist.
+func (s *ImageService) Regenerate(ctx context.Context, productID int) (int64, error) {
+	if productID != 0 {
+		exists, err := s.productRepo.Exists(ctx, productID)
+		if err != nil {
+			return 0, err
+		}
+		if !exists {
+			return -1, nil
+		}
+	}
+
+	queued, err := s.repo.MarkPending(ctx, productID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.logger.Ctx(ctx).Info("Queued images for variant regeneration", logger.Int64("count", queued), logger.Int("product_id", productID))
+	s.notifyWorker()
+
+	return queued, nil
+}
+
+// StartVariantWorker runs concurrency workers that generate the size variants of pending
+// images. Workers are woken by new uploads and regeneration requests, and poll every
+// interval to pick up work queued by other instances.
+func (s *ImageService) StartVariantWorker(ctx context.Context, concurrency int, interval time.Duration) {
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				s.processPendingImages(ctx)
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				case <-s.wake:
+				}
+			}
+		}()
+	}
+}
+
+func (s *ImageService) notifyWorker() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *ImageService) processPendingImages(ctx context.Context) {
+	for ctx.Err() == nil {
+		images, err := s.repo.ClaimPending(ctx, time.Now().Add(-variantStaleAfter), 1)
+		if err != nil {
+			s.logger.Ctx(ctx).Error("Failed to claim pending images", logger.Err(err))
+			return
+		}
+		if len(images) == 0 {
+			return
+		}
+
+		// Hand any remaining work to an idle worker
+		s.notifyWorker()
+
+		img := images[0]
+		if err := s.generateVariants(ctx, img); err != nil {
+			s.logger.Ctx(ctx).Error("Failed to generate image variants", logger.Int("image_id", img.ID), logger.Err(err))
+			if err := s.repo.SetStatus(ctx, img.ID, model.ImageStatusFailed); err != nil {
+				s.logger.Ctx(ctx).Error("Failed to mark image as failed", logger.Int("image_id", img.ID), logger.Err(err))
+			}
+		}
+		s.products.invalidateProduct(ctx, img.ProductID)
+	}
+}
+
+func (s *ImageService) generateVariants(ctx context.Context, img *model.ProductImage) error {
+	body, _, err := s.blob.Get(ctx, img.StorageKey)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return err
+	}
+
+	renditions, err := imaging.Render(data, s.presets)
+	if err != nil {
+		return err
+	}
+
+	variants := make([]model.ProductImageVariant, 0, len(renditions))
+	keys := make([]string, 0, len(renditions))
+	for _, r := range renditions {
+		// Every generation gets fresh keys so cached URLs never serve stale bytes
+		key := fmt.Sprintf("products/%d/variants/%s%s", img.ProductID, uuid.New().String(), r.Extension)
+		if err := s.blob.Put(ctx, key, bytes.NewReader(r.Data), int64(len(r.Data)), r.ContentType); err != nil {
+			s.removeFiles(ctx, keys)
+			return err
+		}
+		keys = append(keys, key)
+
+		variants = append(variants, model.ProductImageVariant{
+			Name:        r.Preset.Name,
+			StorageKey:  key,
+			ContentType: r.ContentType,
+			Size:        int64(len(r.Data)),
+			Width:       r.Width,
+			Height:      r.Height,
+		})
+	}
+
+	old, err := s.repo.ReplaceVariants(ctx, img.ID, variants)
+	if err != nil {
+		s.removeFiles(ctx, keys)
+		if errors.Is(err, repository.ErrImageNotFound) {
+			// The image was deleted while its variants were being generated
+			return nil
+		}
+		return err
+	}
+
+	oldKeys := make([]string, 0, len(old))
+	for _, variant := range old {
+		oldKeys = append(oldKeys, variant.StorageKey)
+	}
+	s.removeFiles(ctx, oldKeys)
+
+	s.logger.Ctx(ctx).Info("Generated image variants", logger.Int("image_id", img.ID), logger.Int("count", len(variants)))
+	return nil
+}
+
+// removeFiles deletes stored files in parallel, logging failures rather than returning them
+func (s *ImageService) removeFiles(ctx context.Context, keys []string) {
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			if err := s.blob.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				s.logger.Ctx(ctx).Error("Failed to remove image file", logger.String("key", key), logger.Err(err))
+			}
+		}(key)
+	}
+	wg.Wait()
+}
+
+func toImageResponse(img *model.ProductImage) *model.ImageResponse {
+	variants := make([]model.ImageVariantResponse, 0, len(img.Variants))
+	for _, variant := range img.Variants {
+		variants = append(variants, model.ImageVariantResponse{
+			Name:        variant.Name,
+			URL:         MediaPathPrefix + variant.StorageKey,
+			ContentType: variant.ContentType,
+			Size:        variant.Size,
+			Width:       variant.Width,
+			Height:      variant.Height,
+		})
+	}
+
+	return &model.ImageResponse{
+		ID:          img.ID,
+		URL:         MediaPathPrefix + img.StorageKey,
+		ContentType: img.ContentType,
+		Size:        img.Size,
+		Width:       img.Width,
+		Height:      img.Height,
+		Position:    img.Position,
+		IsPrimary:   img.IsPrimary,
+		Alt:         img.Alt,
+		Status:      img.Status,
+		CreatedAt:   img.CreatedAt,
+		Variants:    variants,
+	}
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"product-crud/internal/model"
+	"product-crud/internal/repository"
+	"product-crud/internal/validation"
+	"product-crud/pkg/logger"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultLedgerLimit = 100
+	sweepBatchSize     = 500
+)
+
+type InventoryService struct {
+	repo           *repository.InventoryRepository
+	productRepo    *repository.ProductRepository
+	logger         *logger.Logger
+	reservationTTL time.Duration
+}
+
+func NewInventoryService(repo *repository.InventoryRepository, productRepo *repository.ProductRepository, logger *logger.Logger, reservationTTL time.Duration) *InventoryService {
+	return &InventoryService{
+		repo:           repo,
+		productRepo:    productRepo,
+		logger:         logger,
+		reservationTTL: reservationTTL,
+	}
+}
+
+// GetStock returns the stock of a product, or nil if the product does not exist
+func (s *InventoryService) GetStock(ctx context.Context, productID int) (*model.StockResponse, error) {
+	exists, err := s.productRepo.Exists(ctx, productID)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	stock, err := s.repo.GetStock(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toStockResponse(stock), nil
+}
+
+// Adjust applies a stock movement, or returns nil if the product does not exist
+func (s *InventoryService) Adjust(ctx context.Context, productID int, req *model.AdjustStockRequest) (*model.StockResponse, error) {
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if (req.Reason == model.StockReasonReceipt && req.Delta < 0) || (req.Reason == model.StockReasonSale && req.Delta > 0) {
+		return nil, validation.Errors{{
+			Field:   "delta",
+			Code:    "invalid_sign",
+			Message: "must be positive for receipts and negative for sales",
+		}}
+	}
+
+	exists, err := s.productRepo.Exists(ctx, productID)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	stock, err := s.repo.Adjust(ctx, productID, req.Delta, req.Reason, req.Reference)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Ctx(ctx).Info("Stock adjusted",
+		logger.Int("product_id", productID),
+		logger.Int("delta", req.Delta),
+		logger.String("reason", req.Reason),
+		logger.Int("on_hand", stock.OnHand))
+
+	return toStockResponse(stock), nil
+}
+
+// GetLedger returns the latest stock movements of a product, or nil if the product does not exist
+func (s *InventoryService) GetLedger(ctx context.Context, productID int) ([]*model.StockMovement, error) {
+	exists, err := s.productRepo.Exists(ctx, productID)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	return s.repo.GetMovements(ctx, productID, defaultLedgerLimit)
+}
+
+// Reserve holds stock for a client, or returns nil if the product does not exist
+func (s *InventoryService) Reserve(ctx context.Context, productID int, req *model.CreateReservationRequest) (*model.ReservationResponse, error) {
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.productRepo.Exists(ctx, productID)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	ttl := s.reservationTTL
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+
+	reservation := &model.Reservation{
+		ID:        uuid.New().String(),
+		ProductID: productID,
+		Quantity:  req.Quantity,
+		Reference: req.Reference,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	if err := s.repo.Reserve(ctx, reservation); err != nil {
+		return nil, err
+	}
+
+	return toReservationResponse(reservation), nil
+}
+
+func (s *InventoryService) GetReservation(ctx context.Context, id string) (*model.ReservationResponse, error) {
+	reservation, err := s.repo.GetReservation(ctx, id)
+	if err != nil || reservation == nil {
+		return nil, err
+	}
+
+	return toReservationResponse(reservation), nil
+}
+
+func (s *InventoryService) CommitReservation(ctx context.Context, id string) (*model.ReservationResponse, error) {
+	reservation, err := s.repo.Commit(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toReservationResponse(reservation), nil
+}
+
+func (s *InventoryService) ReleaseReservation(ctx context.Context, id string) (*model.ReservationResponse, error) {
+	reservation, err := s.repo.Release(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toReservationResponse(reservation), nil
+}
+
+// StartReservationSweeper releases expired reservations every interval until ctx is cancelled
+func (s *InventoryService) StartReservationSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.sweepExpiredReservations(ctx)
+			}
+		}
+	}()
+}
+
+func (s *InventoryService) sweepExpiredReservations(ctx context.Context) {
+	for {
+		released, err := s.repo.ReleaseExpired(ctx, time.Now(), sweepBatchSize)
+		if err != nil {
+			s.logger.Error("Failed to release expired reservations", logger.Err(err))
+			return
+		}
+
+		if released > 0 {
+			s.logger.Info("Released expired reservations", logger.Int("count", released))
+		}
+
+		if released < sweepBatchSize {
+			return
+		}
+	}
+}
+
+func toStockResponse(stock *model.StockLevel) *model.StockResponse {
+	return &model.StockResponse{
+		ProductID: stock.ProductID,
+		OnHand:    stock.OnHand,
+		Reserved:  stock.Reserved,
+		Available: stock.OnHand - stock.Reserved,
+		UpdatedAt: stock.UpdatedAt,
+	}
+}
+
+func toReservationResponse(reservation *model.Reservation) *model.ReservationResponse {
+	return &model.ReservationResponse{
+		ID:        reservation.ID,
+		ProductID: reservation.ProductID,
+		Quantity:  reservation.Quantity,
+		Status:    reservation.Status,
+		Reference: reservation.Reference,
+		ExpiresAt: reservation.ExpiresAt,
+		CreatedAt: reservation.CreatedAt,
+	}
+}
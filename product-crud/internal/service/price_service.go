@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"product-crud/internal/model"
+	"product-crud/internal/repository"
+	"product-crud/internal/validation"
+	"product-crud/pkg/db"
+	"product-crud/pkg/logger"
+	"product-crud/pkg/money"
+	"time"
+)
+
+const priceBatchSize = 100
+
+type PriceService struct {
+	repo        *repository.PriceRepository
+	productRepo *repository.ProductRepository
+	products    *ProductService
+	logger      *logger.Logger
+}
+
+func NewPriceService(repo *repository.PriceRepository, productRepo *repository.ProductRepository, products *ProductService, logger *logger.Logger) *PriceService {
+	return &PriceService{
+		repo:        repo,
+		productRepo: productRepo,
+		products:    products,
+		logger:      logger,
+	}
+}
+
+// Schedule records a future price change, or returns nil if the product does not exist
+func (s *PriceService) Schedule(ctx context.Context, productID int, req *model.SchedulePriceRequest) (*model.PriceHistoryResponse, error) {
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if !req.EffectiveFrom.After(time.Now()) {
+		return nil, validation.Errors{{
+			Field:   "effective_from",
+			Code:    "out_of_range",
+			Message: "must be in the future",
+		}}
+	}
+
+	product, err := s.productRepo.GetByID(db.WithPrimary(ctx), productID)
+	if err != nil || product == nil {
+		return nil, err
+	}
+
+	currency := product.Currency
+	if req.Currency != "" {
+		currency = money.NormalizeCurrency(req.Currency)
+	}
+
+	if !money.FitsScale(req.Price, currency) {
+		return nil, validation.Errors{{
+			Field:   "price",
+			Code:    "too_precise",
+			Message: "has more decimal places than the currency allows",
+		}}
+	}
+
+	entry := &model.PriceHistory{
+		ProductID:     productID,
+		Amount:        req.Price,
+		Currency:      currency,
+		EffectiveFrom: req.EffectiveFrom,
+		EffectiveTo:   req.EffectiveTo,
+	}
+
+	if err := s.repo.Schedule(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	s.logger.Ctx(ctx).Info("Scheduled price change", logger.Int("product_id", productID), logger.Int("price_id", entry.ID), logger.Time("effective_from", entry.EffectiveFrom))
+
+	return toPriceHistoryResponse(entry), nil
+}
+
+// GetHistory returns the past, current and scheduled prices of a product, or nil if it does not exist
+func (s *PriceService) GetHistory(ctx context.Context, productID int) ([]*model.PriceHistoryResponse, error) {
+	exists, err := s.productRepo.Exists(ctx, productID)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	entries, err := s.repo.GetHistory(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]*model.PriceHistoryResponse, 0, len(entries))
+	for _, entry := range entries {
+		response = append(response, toPriceHistoryResponse(entry))
+	}
+
+	return response, nil
+}
+
+// PriceAt returns the price of a product at the given time, or nil if it had no price then
+func (s *PriceService) PriceAt(ctx context.Context, productID int, at time.Time) (*model.PriceAtResponse, error) {
+	entry, err := s.repo.PriceAt(ctx, productID, at)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+
+	return &model.PriceAtResponse{
+		ProductID: productID,
+		At:        at,
+		Price:     entry.Amount,
+		Currency:  entry.Currency,
+		EntryID:   entry.ID,
+	}, nil
+}
+
+// Cancel removes a scheduled price that has not taken effect yet
+func (s *PriceService) Cancel(ctx context.Context, productID, id int) error {
+	return s.repo.Cancel(ctx, productID, id)
+}
+
+// StartPriceScheduler applies due price changes every interval until ctx is cancelled
+func (s *PriceService) StartPriceScheduler(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.applyDuePrices(ctx)
+			}
+		}
+	}()
+}
+
+func (s *PriceService) applyDuePrices(ctx context.Context) {
+	now := time.Now()
+
+	ids, err := s.repo.DueProducts(ctx, now, priceBatchSize)
+	if err != nil {
+		s.logger.Ctx(ctx).Error("Failed to find due price changes", logger.Err(err))
+		return
+	}
+
+	for _, id := range ids {
+		changed, err := s.repo.ApplyDue(ctx, id, now)
+		if err != nil {
+			s.logger.Ctx(ctx).Error("Failed to apply price change", logger.Int("product_id", id), logger.Err(err))
+			continue
+		}
+
+		if changed {
+			s.logger.Ctx(ctx).Info("Applied scheduled price change", logger.Int("product_id", id))
+			s.products.invalidateProduct(ctx, id)
+		}
+	}
+}
+
+func toPriceHistoryResponse(entry *model.PriceHistory) *model.PriceHistoryResponse {
+	status := model.PriceScheduled
+	switch {
+	case entry.EndedAt != nil:
+		status = model.PriceEnded
+	case entry.AppliedAt != nil:
+		status = model.PriceActive
+	}
+
+	return &model.PriceHistoryResponse{
+		ID:            entry.ID,
+		Price:         entry.Amount,
+		Currency:      entry.Currency,
+		EffectiveFrom: entry.EffectiveFrom,
+		EffectiveTo:   entry.EffectiveTo,
+		Status:        status,
+		CreatedAt:     entry.CreatedAt,
+	}
+}
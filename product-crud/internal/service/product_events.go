@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"product-crud/pkg/logger"
+	"sync"
+	"time"
+)
+
+// Product event types
+const (
+	ProductCreated = "created"
+	ProductUpdated = "updated"
+	ProductDeleted = "deleted"
+)
+
+// subscriberBuffer is how many events a slow subscriber may fall behind before events are dropped
+const subscriberBuffer = 64
+
+// ProductEvent reports that a product changed
+type ProductEvent struct {
+	Type       string
+	ProductID  int
+	OccurredAt time.Time
+}
+
+// productEvents fans product events out to in-process subscribers. Publishing never
+// blocks: a subscriber whose buffer is full misses the event.
+type productEvents struct {
+	mu          sync.RWMutex
+	subscribers map[chan ProductEvent]struct{}
+	logger      *logger.Logger
+}
+
+func newProductEvents(logger *logger.Logger) *productEvents {
+	return &productEvents{
+		subscribers: make(map[chan ProductEvent]struct{}),
+		logger:      logger,
+	}
+}
+
+func (e *productEvents) publish(eventType string, productID int) {
+	event := ProductEvent{
+		Type:       eventType,
+		ProductID:  productID,
+		OccurredAt: time.Now(),
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	for ch := range e.subscribers {
+		select {
+		case ch <- event:
+		default:
+			e.logger.Warn("Dropped product event for slow subscriber", logger.String("type", eventType), logger.Int("product_id", productID))
+		}
+	}
+}
+
+// subscribe registers a subscriber until ctx is cancelled, after which the channel is closed
+func (e *productEvents) subscribe(ctx context.Context) <-chan ProductEvent {
+	ch := make(chan ProductEvent, subscriberBuffer)
+
+	e.mu.Lock()
+	e.subscribers[ch] = struct{}{}
+	e.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		e.mu.Lock()
+		delete(e.subscribers, ch)
+		e.mu.Unlock()
+		close(ch)
+	}()
+
+	return ch
+}
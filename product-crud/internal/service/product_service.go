@@ -5,147 +5,292 @@ import (
 	"fmt"
 	"product-crud/internal/model"
 	"product-crud/internal/repository"
+	"product-crud/internal/validation"
 	"product-crud/pkg/cache"
+	"product-crud/pkg/db"
 	"product-crud/pkg/logger"
+	"product-crud/pkg/money"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 const (
-	productKeyPrefix = "product:"
-	allProductsKey   = "products:all"
+	productKeyPrefix     = "product:"
+	productListKeyPrefix = "products:list:"
+	productIDFilterKey   = "bloom:product_ids"
+	productPopularityKey = "stats:product_requests"
+	// productListGenerationKey names the generation of the cached listings; see productListKey
+	productListGenerationKey = "products:list_generation"
 )
 
+// idFilterRebuildMargin covers products whose creation was in flight while the ID
+// filter was being rebuilt
+const idFilterRebuildMargin = 5 * time.Minute
+
 type ProductService struct {
-	repo  *repository.ProductRepository
-	cache *cache.RedisCache
-	logger *logger.Logger
+	repo         *repository.ProductRepository
+	categoryRepo *repository.CategoryRepository
+	cache        *cache.RedisCache
+	logger       *logger.Logger
+	events       *productEvents
+	// idFilter, when set, rejects IDs that were never created without a database query
+	idFilter *cache.BloomFilter
+	// rebuildingIDFilter is set while a background rebuild of idFilter runs
+	rebuildingIDFilter atomic.Bool
+	// popularity counts requests per product, to know which ones to warm up
+	popularity *cache.Popularity
 }
 
-func NewProductService(repo *repository.ProductRepository, cache *cache.RedisCache, logger *logger.Logger) *ProductService {
-	return &ProductService{
-		repo:  repo,
-		cache: cache,
-		logger: logger,
+// NewProductService creates the service. A non-zero idFilterCapacity enables a Bloom
+// filter of existing product IDs sized for that many products; see RebuildIDFilter.
+func NewProductService(repo *repository.ProductRepository, categoryRepo *repository.CategoryRepository, cache *cache.RedisCache, logger *logger.Logger, idFilterCapacity int) *ProductService {
+	s := &ProductService{
+		repo:         repo,
+		categoryRepo: categoryRepo,
+		cache:        cache,
+		logger:       logger,
+		events:       newProductEvents(logger),
+	}
+
+	if cache != nil {
+		s.popularity = cachePopularity(cache)
+	}
+	if idFilterCapacity > 0 {
+		s.idFilter = cacheBloomFilter(cache, idFilterCapacity)
 	}
+
+	return s
 }
 
 func (s *ProductService) Create(ctx context.Context, req *model.CreateProductRequest) (*model.ProductResponse, error) {
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	currency := money.NormalizeCurrency(req.Currency)
+	prices, err := toProductPrices(currency, req.Prices)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.resolveCategories(ctx, req.CategoryIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	product := &model.Product{
-		Name:        req.Name,
+		Name:        strings.TrimSpace(req.Name),
 		Description: req.Description,
 		Price:       req.Price,
+		Currency:    currency,
+		Prices:      prices,
+		Categories:  categories,
+		Tags:        toProductTags(req.Tags),
+		Options:     toProductOptions(req.Options),
 	}
 
-	id, err := s.repo.Create(product)
+	id, err := s.repo.Create(ctx, product)
 	if err != nil {
 		return nil, err
 	}
 
-	createdProduct, err := s.repo.GetByID(id)
+	if s.idFilter != nil {
+		s.addToIDFilter(ctx, id)
+	}
+
+	createdProduct, err := s.repo.GetByID(db.WithPrimary(ctx), id)
 	if err != nil {
 		return nil, err
 	}
 
-	response := &model.ProductResponse{
-		ID:          createdProduct.ID,
-		Name:        createdProduct.Name,
-		Description: createdProduct.Description,
-		Price:       createdProduct.Price,
-		CreatedAt:   createdProduct.CreatedAt,
-		UpdatedAt:   createdProduct.UpdatedAt,
-	}
+	response := toProductResponse(createdProduct)
 
+	// Also replaces a cached "not found" left by a lookup of this ID before it existed
 	err = s.cache.Set(ctx, productKey(id), response)
 	if err != nil {
-		fmt.Printf("Failed to cache product %d: %v\n", id, err)
+		s.logger.Ctx(ctx).Error("Failed to cache product", logger.Err(err), logger.Int("product_id", id))
 	}
 
-	if err := s.cache.Delete(ctx, allProductsKey); err != nil {
-		fmt.Printf("Failed to delete all products cache: %v\n", err)
-	}
+	invalidateProductLists(ctx, s.cache, s.logger)
+	s.events.publish(ProductCreated, id)
 
 	return response, nil
 }
 
+// GetByID reads through the cache. Concurrent misses share one database query, a stale
+// entry is served while it is refreshed in the background, and missing IDs are cached
+// too, after consulting the ID filter if there is one. Misses are loaded from the
+// primary: a lagging replica could return the version an invalidation just removed,
+// which the cache would then keep for a whole TTL.
 func (s *ProductService) GetByID(ctx context.Context, id int) (*model.ProductResponse, error) {
 	var product model.ProductResponse
-	found, err := s.cache.Get(ctx, productKey(id), &product)
+	found, err := s.cache.Fetch(ctx, productKey(id), &product, func(ctx context.Context) (interface{}, error) {
+		if s.idFilter != nil {
+			maybe, err := s.idFilter.MightContain(ctx, strconv.Itoa(id))
+			if err != nil {
+				s.logger.Ctx(ctx).Error("Error checking product ID filter", logger.Err(err), logger.Int("product_id", id))
+			}
+			if !maybe {
+				return nil, nil
+			}
+		}
+
+		productFromDB, err := s.repo.GetByID(db.WithPrimary(ctx), id)
+		if err != nil || productFromDB == nil {
+			return nil, err
+		}
+		return toProductResponse(productFromDB), nil
+	})
 	if err != nil {
-		fmt.Printf("Cache error: %v\n", err)
+		return nil, err
 	}
-	
-	if found {
-		s.logger.Info("Cache hit for product ID %d", id)
-		return &product, nil
+
+	if !found {
+		return nil, nil
 	}
-	
-	productFromDB, err := s.repo.GetByID(id)
+
+	s.popularity.Record(strconv.Itoa(id))
+	return &product, nil
+}
+
+// GetByIDs returns the products among ids that exist, keyed by ID. Cached products are
+// fetched in one round trip and the rest are loaded from the database in one query.
+func (s *ProductService) GetByIDs(ctx context.Context, ids []int) (map[int]*model.ProductResponse, error) {
+	result := make(map[int]*model.ProductResponse, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	keys := make([]string, len(ids))
+	cached := make([]model.ProductResponse, len(ids))
+	dests := make([]interface{}, len(ids))
+	for i, id := range ids {
+		keys[i] = productKey(id)
+		dests[i] = &cached[i]
+	}
+
+	found, err := s.cache.GetMany(ctx, keys, dests)
+	if err != nil {
+		s.logger.Ctx(ctx).Error("Cache error", logger.Err(err))
+	}
+
+	var missing []int
+	for i, id := range ids {
+		if found[i] {
+			result[id] = &cached[i]
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	products, err := s.repo.GetByIDs(ctx, missing)
 	if err != nil {
 		return nil, err
 	}
-	
-	if productFromDB == nil {
-		return nil, nil 
+
+	toCache := make(map[string]interface{}, len(products))
+	for _, product := range products {
+		response := toProductResponse(product)
+		result[product.ID] = response
+		toCache[productKey(product.ID)] = response
 	}
-	
-	response := &model.ProductResponse{
-		ID:          productFromDB.ID,
-		Name:        productFromDB.Name,
-		Description: productFromDB.Description,
-		Price:       productFromDB.Price,
-		CreatedAt:   productFromDB.CreatedAt,
-		UpdatedAt:   productFromDB.UpdatedAt,
+
+	if err := s.cache.SetMany(ctx, toCache); err != nil {
+		s.logger.Ctx(ctx).Error("Error caching products", logger.Err(err))
 	}
-	
-	if err := s.cache.Set(ctx, productKey(id), response); err != nil {
-		fmt.Printf("Error caching product: %v\n", err)
+
+	return result, nil
+}
+
+// GetAll reads a listing through the cache like GetByID, from the primary on a miss too,
+// caching each listed product as well. Without the listing generation it skips the cache,
+// since it could not tell a current listing from an invalidated one.
+func (s *ProductService) GetAll(ctx context.Context, filter model.ProductFilter) ([]*model.ProductResponse, error) {
+	filter.Tag = normalizeTag(filter.Tag)
+
+	generation, err := cacheListGeneration(s.cache).Current(ctx)
+	if err != nil {
+		s.logger.Ctx(ctx).Error("Error reading product listing generation", logger.Err(err))
+		return s.loadList(ctx, filter)
 	}
-	
+
+	var response []*model.ProductResponse
+	_, err = s.cache.Fetch(ctx, productListKey(generation, filter), &response, func(ctx context.Context) (interface{}, error) {
+		return s.loadList(ctx, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return response, nil
 }
 
-func (s *ProductService) GetAll(ctx context.Context) ([]*model.ProductResponse, error) {
-	var cachedProducts []*model.ProductResponse
-	found, err := s.cache.Get(ctx, allProductsKey, &cachedProducts)
+// loadList reads a listing from the primary and caches each listed product
+func (s *ProductService) loadList(ctx context.Context, filter model.ProductFilter) ([]*model.ProductResponse, error) {
+	products, err := s.repo.GetAll(db.WithPrimary(ctx), filter)
 	if err != nil {
-		fmt.Printf("Cache error: %v\n", err)
+		return nil, err
 	}
-	
-	if found && len(cachedProducts) > 0 {
-		s.logger.Info("Cache hit for all products")
-		return cachedProducts, nil
+
+	list := make([]*model.ProductResponse, 0, len(products))
+	toCache := make(map[string]interface{}, len(products))
+	for _, product := range products {
+		productResponse := toProductResponse(product)
+		list = append(list, productResponse)
+		toCache[productKey(product.ID)] = productResponse
 	}
-	
-	products, err := s.repo.GetAll()
+
+	if err := s.cache.SetMany(ctx, toCache); err != nil {
+		s.logger.Ctx(ctx).Error("Error caching products", logger.Err(err))
+	}
+
+	return list, nil
+}
+
+// GetPage lists up to limit products matching filter with IDs above afterID, in ID order.
+// Pages are read from the database rather than the cache, as every cursor would make a
+// listing of its own to invalidate.
+func (s *ProductService) GetPage(ctx context.Context, filter model.ProductFilter, afterID, limit int) ([]*model.ProductResponse, error) {
+	filter.Tag = normalizeTag(filter.Tag)
+
+	products, err := s.repo.GetPage(ctx, filter, afterID, limit)
 	if err != nil {
 		return nil, err
 	}
-	
-	var response []*model.ProductResponse
+
+	response := make([]*model.ProductResponse, 0, len(products))
 	for _, product := range products {
-		productResponse := &model.ProductResponse{
-			ID:          product.ID,
-			Name:        product.Name,
-			Description: product.Description,
-			Price:       product.Price,
-			CreatedAt:   product.CreatedAt,
-			UpdatedAt:   product.UpdatedAt,
-		}
-		response = append(response, productResponse)
-		
-		if err := s.cache.Set(ctx, productKey(product.ID), productResponse); err != nil {
-			fmt.Printf("Error caching product: %v\n", err)
-		}
+		response = append(response, toProductResponse(product))
 	}
-	
-	if err := s.cache.Set(ctx, allProductsKey, response); err != nil {
-		fmt.Printf("Error caching all products: %v\n", err)
-	}
-	
+
 	return response, nil
 }
 
+// Count returns how many products match filter
+func (s *ProductService) Count(ctx context.Context, filter model.ProductFilter) (int, error) {
+	filter.Tag = normalizeTag(filter.Tag)
+
+	count, err := s.repo.Count(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
 func (s *ProductService) Update(ctx context.Context, id int, req *model.UpdateProductRequest) (*model.ProductResponse, error) {
-	existingProduct, err := s.repo.GetByID(id)
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	// Read-modify-write, so read what the update will overwrite
+	existingProduct, err := s.repo.GetByID(db.WithPrimary(ctx), id)
 	if err != nil {
 		return nil, err
 	}
@@ -155,65 +300,460 @@ func (s *ProductService) Update(ctx context.Context, id int, req *model.UpdatePr
 	}
 	
 	if req.Name != "" {
-		existingProduct.Name = req.Name
+		existingProduct.Name = strings.TrimSpace(req.Name)
 	}
 	
 	if req.Description != "" {
 		existingProduct.Description = req.Description
 	}
 	
-	if req.Price > 0 {
+	if req.Price.IsPositive() {
 		existingProduct.Price = req.Price
 	}
+
+	if req.Currency != "" {
+		existingProduct.Currency = money.NormalizeCurrency(req.Currency)
+	}
+
+	// The request may omit the currency, so only here is the one the price is in known
+	if !money.FitsScale(existingProduct.Price, existingProduct.Currency) {
+		return nil, validation.Errors{{
+			Field:   "price",
+			Code:    "too_precise",
+			Message: "has more decimal places than the currency allows",
+		}}
+	}
+
+	existingPrices := existingProduct.Prices
+	existingProduct.Prices = nil
+	if req.Prices != nil {
+		existingProduct.Prices, err = toProductPrices(existingProduct.Currency, req.Prices)
+		if err != nil {
+			return nil, err
+		}
+	} else if req.Currency != "" {
+		// The new default currency must not also remain an additional price
+		existingProduct.Prices = make([]model.ProductPrice, 0, len(existingPrices))
+		for _, p := range existingPrices {
+			if p.Currency != existingProduct.Currency {
+				existingProduct.Prices = append(existingProduct.Prices, p)
+			}
+		}
+	}
+
+	existingProduct.Categories = nil
+	if req.CategoryIDs != nil {
+		existingProduct.Categories, err = s.resolveCategories(ctx, req.CategoryIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	existingProduct.Tags = nil
+	if req.Tags != nil {
+		existingProduct.Tags = toProductTags(req.Tags)
+	}
+
+	if req.Options != nil {
+		options := toProductOptions(req.Options)
+		for _, variant := range existingProduct.Variants {
+			if errs := checkOptionValues(options, variant.OptionValues); len(errs) > 0 {
+				return nil, validation.Errors{{
+					Field:   "options",
+					Code:    "in_use",
+					Message: fmt.Sprintf("must keep the options and values used by variant %s", variant.SKU),
+				}}
+			}
+		}
+		existingProduct.Options = options
+	} else {
+		existingProduct.Options = nil
+	}
 	
-	err = s.repo.Update(id, existingProduct)
+	err = s.repo.Update(ctx, id, existingProduct)
 	if err != nil {
 		return nil, err
 	}
 	
-	updatedProduct, err := s.repo.GetByID(id)
+	updatedProduct, err := s.repo.GetByID(db.WithPrimary(ctx), id)
 	if err != nil {
 		return nil, err
 	}
 	
-	response := &model.ProductResponse{
-		ID:          updatedProduct.ID,
-		Name:        updatedProduct.Name,
-		Description: updatedProduct.Description,
-		Price:       updatedProduct.Price,
-		CreatedAt:   updatedProduct.CreatedAt,
-		UpdatedAt:   updatedProduct.UpdatedAt,
-	}
+	response := toProductResponse(updatedProduct)
 	
 	if err := s.cache.Set(ctx, productKey(id), response); err != nil {
-		fmt.Printf("Error updating product in cache: %v\n", err)
+		s.logger.Ctx(ctx).Error("Error updating product in cache", logger.Err(err), logger.Int("product_id", id))
 	}
 	
-	if err := s.cache.Delete(ctx, allProductsKey); err != nil {
-		fmt.Printf("Error invalidating all products cache: %v\n", err)
-	}
+	invalidateProductLists(ctx, s.cache, s.logger)
+	s.events.publish(ProductUpdated, id)
 	
 	return response, nil
 }
 
 func (s *ProductService) Delete(ctx context.Context, id int) error {
-	err := s.repo.Delete(id)
+	err := s.repo.Delete(ctx, id)
 	if err != nil {
 		return err
 	}
 	
-	if err := s.cache.Delete(ctx, productKey(id)); err != nil {
-		fmt.Printf("Error removing product from cache: %v\n", err)
+	// A tombstone rather than a plain delete, so a read that loaded the product before
+	// it was deleted cannot cache it again
+	if err := s.cache.Retire(ctx, productKey(id)); err != nil {
+		s.logger.Ctx(ctx).Error("Error removing product from cache", logger.Err(err), logger.Int("product_id", id))
 	}
 	
-	if err := s.cache.Delete(ctx, allProductsKey); err != nil {
-		fmt.Printf("Error invalidating all products cache: %v\n", err)
-	}
+	invalidateProductLists(ctx, s.cache, s.logger)
+	s.events.publish(ProductDeleted, id)
 	
 	return nil
 }
 
+// RebuildIDFilter rebuilds the product ID filter from the database, dropping deleted
+// products. Products created while it runs, on any instance, are added back afterwards.
+func (s *ProductService) RebuildIDFilter(ctx context.Context) error {
+	if s.idFilter == nil {
+		return nil
+	}
+
+	started := time.Now()
+	ids, err := s.repo.GetIDs(ctx, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	if err := s.idFilter.Rebuild(ctx, formatIDs(ids)); err != nil {
+		return err
+	}
+
+	recent, err := s.repo.GetIDs(ctx, started.Add(-idFilterRebuildMargin))
+	if err != nil {
+		return err
+	}
+
+	// Should the filter have been dropped meanwhile, it lets everything through until the
+	// next rebuild
+	if _, err := s.idFilter.Add(ctx, formatIDs(recent)...); err != nil {
+		return err
+	}
+
+	s.logger.Ctx(ctx).Info("Rebuilt product ID filter", logger.Int("products", len(ids)))
+	return nil
+}
+
+// StartIDFilterRebuild rebuilds the product ID filter every interval until ctx is
+// cancelled, repairing it after Redis lost it and dropping deleted products
+func (s *ProductService) StartIDFilterRebuild(ctx context.Context, interval time.Duration) {
+	if s.idFilter == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.RebuildIDFilter(ctx); err != nil {
+					s.logger.Error("Failed to rebuild product ID filter", logger.Err(err))
+				}
+			}
+		}
+	}()
+}
+
+// addToIDFilter records a new product in the ID filter. If that fails the filter is
+// dropped, as it would reject the product otherwise, and rebuilt in the background, as
+// it also is when found missing.
+func (s *ProductService) addToIDFilter(ctx context.Context, id int) {
+	log := s.logger.Ctx(ctx)
+
+	exists, err := s.idFilter.Add(ctx, strconv.Itoa(id))
+	if err != nil {
+		log.Error("Error adding product to ID filter, dropping the filter", logger.Err(err), logger.Int("product_id", id))
+		if err := s.idFilter.Drop(ctx); err != nil {
+			log.Error("Error dropping product ID filter, retrying once Redis is back", logger.Err(err))
+		}
+	}
+
+	if err != nil || !exists {
+		s.rebuildIDFilterInBackground(ctx)
+	}
+}
+
+// rebuildIDFilterInBackground starts a rebuild unless one is running already
+func (s *ProductService) rebuildIDFilterInBackground(ctx context.Context) {
+	if !s.rebuildingIDFilter.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer s.rebuildingIDFilter.Store(false)
+
+		ctx := context.WithoutCancel(ctx)
+		if err := s.RebuildIDFilter(ctx); err != nil {
+			s.logger.Ctx(ctx).Error("Failed to rebuild product ID filter", logger.Err(err))
+		}
+	}()
+}
+
+// WarmUp loads the count most requested products into the cache, returning how many are cached
+func (s *ProductService) WarmUp(ctx context.Context, count int) (int, error) {
+	top, err := s.popularity.Top(ctx, count)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]int, 0, len(top))
+	for _, member := range top {
+		if id, err := strconv.Atoi(member); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	products, err := s.GetByIDs(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	s.logger.Ctx(ctx).Info("Warmed up product cache", logger.Int("requested", count), logger.Int("cached", len(products)))
+	return len(products), nil
+}
+
+// StartPopularityTracking shares the request counts used by WarmUp with the other
+// instances every interval until ctx is cancelled
+func (s *ProductService) StartPopularityTracking(ctx context.Context, interval time.Duration) {
+	s.popularity.Start(ctx, interval)
+}
+
+// Subscribe streams product changes made through this instance until ctx is cancelled
+func (s *ProductService) Subscribe(ctx context.Context) <-chan ProductEvent {
+	return s.events.subscribe(ctx)
+}
+
+
+// resolveCategories loads the categories to attach to a product, failing with a
+// field error if any of the IDs does not exist
+func (s *ProductService) resolveCategories(ctx context.Context, ids []int) ([]model.Category, error) {
+	categories, err := s.categoryRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(categories) != len(ids) {
+		return nil, validation.Errors{{
+			Field:   "category_ids",
+			Code:    "not_found",
+			Message: "must reference existing categories",
+		}}
+	}
+
+	return categories, nil
+}
+
+// invalidateProduct refreshes a product after a change to rows it owns, such as variants,
+// images or scheduled prices, and drops every listing it may appear in. The change bumped
+// the product's updated_at, so the fresh copy is cached through the versioned write and a
+// read that started before the change cannot replace it. If the product cannot be reloaded
+// it is retired instead.
+func (s *ProductService) invalidateProduct(ctx context.Context, id int) {
+	product, err := s.repo.GetByID(db.WithPrimary(ctx), id)
+	if err != nil {
+		s.logger.Ctx(ctx).Error("Error reloading product", logger.Err(err), logger.Int("product_id", id))
+	}
+
+	if product != nil {
+		err = s.cache.Set(ctx, productKey(id), toProductResponse(product))
+	} else {
+		err = s.cache.Retire(ctx, productKey(id))
+	}
+	if err != nil {
+		s.logger.Ctx(ctx).Error("Error refreshing product in cache", logger.Err(err), logger.Int("product_id", id))
+	}
+
+	invalidateProductLists(ctx, s.cache, s.logger)
+	s.events.publish(ProductUpdated, id)
+}
+
+// cacheBloomFilter sizes the product ID filter for capacity products at a 1% false positive rate
+func cacheBloomFilter(c *cache.RedisCache, capacity int) *cache.BloomFilter {
+	return cache.NewBloomFilter(c, productIDFilterKey, capacity, 0.01)
+}
+
+func cacheListGeneration(c *cache.RedisCache) *cache.Generation {
+	return cache.NewGeneration(c, productListGenerationKey)
+}
+
+func cachePopularity(c *cache.RedisCache) *cache.Popularity {
+	return cache.NewPopularity(c, productPopularityKey)
+}
+
+func formatIDs(ids []int) []string {
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, strconv.Itoa(id))
+	}
+	return result
+}
 
 func productKey(id int) string {
 	return fmt.Sprintf("%s%d", productKeyPrefix, id)
 }
+
+// productListKey returns the cache key of a listing in the given generation. Listings are
+// cached without a version of their own, so invalidation moves to a new generation instead of
+// deleting them: a fill that read the database before the change writes a key that is no
+// longer read.
+func productListKey(generation string, filter model.ProductFilter) string {
+	if filter.IsEmpty() {
+		return fmt.Sprintf("%s%s:all", productListKeyPrefix, generation)
+	}
+
+	category := filter.CategorySlug
+	if filter.CategoryID != 0 {
+		category = strconv.Itoa(filter.CategoryID)
+	}
+	return fmt.Sprintf("%s%s:category=%s:tag=%s", productListKeyPrefix, generation, category, filter.Tag)
+}
+
+// invalidateProductLists drops every cached listing, filtered or not, by starting a new
+// listing generation. The listings of previous generations are cleared only to free memory.
+func invalidateProductLists(ctx context.Context, c *cache.RedisCache, log *logger.Logger) {
+	if err := cacheListGeneration(c).Bump(ctx); err != nil {
+		log.Ctx(ctx).Error("Error invalidating product list caches", logger.Err(err))
+	}
+
+	if err := c.Clear(ctx, productListKeyPrefix+"*"); err != nil {
+		log.Ctx(ctx).Error("Error invalidating product list caches", logger.Err(err))
+	}
+}
+
+// invalidateProducts drops cached listings and single products, used when data
+// embedded in every product payload changes
+func invalidateProducts(ctx context.Context, c *cache.RedisCache, log *logger.Logger) {
+	invalidateProductLists(ctx, c, log)
+
+	if err := c.Clear(ctx, productKeyPrefix+"*"); err != nil {
+		log.Ctx(ctx).Error("Error invalidating product caches", logger.Err(err))
+	}
+}
+
+func toProductResponse(product *model.Product) *model.ProductResponse {
+	prices := make([]money.Money, 0, len(product.Prices)+1)
+	prices = append(prices, money.New(product.Price, product.Currency))
+	for _, p := range product.Prices {
+		prices = append(prices, money.New(p.Amount, p.Currency))
+	}
+
+	categories := make([]model.CategorySummary, 0, len(product.Categories))
+	for _, c := range product.Categories {
+		categories = append(categories, model.CategorySummary{
+			ID:   c.ID,
+			Name: c.Name,
+			Slug: c.Slug,
+			Path: c.Path,
+		})
+	}
+
+	tags := make([]string, 0, len(product.Tags))
+	for _, t := range product.Tags {
+		tags = append(tags, t.Tag)
+	}
+
+	options := make([]model.ProductOptionResponse, 0, len(product.Options))
+	for _, o := range product.Options {
+		options = append(options, model.ProductOptionResponse{Name: o.Name, Values: o.Values})
+	}
+
+	variants := make([]model.VariantResponse, 0, len(product.Variants))
+	for i := range product.Variants {
+		variants = append(variants, *toVariantResponse(product, &product.Variants[i]))
+	}
+
+	images := make([]model.ImageResponse, 0, len(product.Images))
+	for i := range product.Images {
+		images = append(images, *toImageResponse(&product.Images[i]))
+	}
+
+	return &model.ProductResponse{
+		ID:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		Currency:    product.Currency,
+		Prices:      prices,
+		Categories:  categories,
+		Tags:        tags,
+		Options:     options,
+		Variants:    variants,
+		Images:      images,
+		CreatedAt:   product.CreatedAt,
+		UpdatedAt:   product.UpdatedAt,
+	}
+}
+
+// toProductPrices converts the requested additional prices, rejecting any that repeat the default currency
+func toProductPrices(defaultCurrency string, prices []money.Money) ([]model.ProductPrice, error) {
+	result := make([]model.ProductPrice, 0, len(prices))
+	seen := make(map[string]bool, len(prices))
+	for i, p := range prices {
+		currency := money.NormalizeCurrency(p.Currency)
+		if currency == defaultCurrency {
+			return nil, validation.Errors{{
+				Field:   fmt.Sprintf("prices[%d].currency", i),
+				Code:    "duplicate",
+				Message: "must differ from the product's default currency",
+			}}
+		}
+		// Validation compared the codes as given, so "usd" and "USD" both got through
+		if seen[currency] {
+			return nil, validation.Errors{{
+				Field:   fmt.Sprintf("prices[%d].currency", i),
+				Code:    "duplicate",
+				Message: "must not repeat the currency of another price",
+			}}
+		}
+		seen[currency] = true
+
+		result = append(result, model.ProductPrice{
+			Currency: currency,
+			Amount:   p.Amount,
+		})
+	}
+	return result, nil
+}
+
+// toProductTags lower-cases and de-duplicates tags
+func toProductTags(tags []string) []model.ProductTag {
+	result := make([]model.ProductTag, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, model.ProductTag{Tag: tag})
+	}
+	return result
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+func toProductOptions(options []model.ProductOptionRequest) []model.ProductOption {
+	result := make([]model.ProductOption, 0, len(options))
+	for i, o := range options {
+		result = append(result, model.ProductOption{
+			Name:     strings.TrimSpace(o.Name),
+			Values:   o.Values,
+			Position: i,
+		})
+	}
+	return result
+}
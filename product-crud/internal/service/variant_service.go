@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"product-crud/internal/model"
+	"product-crud/internal/repository"
+	"product-crud/internal/validation"
+	"product-crud/pkg/db"
+	"product-crud/pkg/logger"
+	"product-crud/pkg/money"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+type VariantService struct {
+	repo        *repository.VariantRepository
+	productRepo *repository.ProductRepository
+	products    *ProductService
+	logger      *logger.Logger
+}
+
+func NewVariantService(repo *repository.VariantRepository, productRepo *repository.ProductRepository, products *ProductService, logger *logger.Logger) *VariantService {
+	return &VariantService{
+		repo:        repo,
+		productRepo: productRepo,
+		products:    products,
+		logger:      logger,
+	}
+}
+
+// GetAll returns the variants of a product, or nil if the product does not exist
+func (s *VariantService) GetAll(ctx context.Context, productID int) ([]*model.VariantResponse, error) {
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil || product == nil {
+		return nil, err
+	}
+
+	response := make([]*model.VariantResponse, 0, len(product.Variants))
+	for i := range product.Variants {
+		response = append(response, toVariantResponse(product, &product.Variants[i]))
+	}
+
+	return response, nil
+}
+
+func (s *VariantService) GetByID(ctx context.Context, productID, id int) (*model.VariantResponse, error) {
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil || product == nil {
+		return nil, err
+	}
+
+	variant := findVariant(product, id)
+	if variant == nil {
+		return nil, nil
+	}
+
+	return toVariantResponse(product, variant), nil
+}
+
+// GetBySKU resolves a SKU to its variant together with the parent product
+func (s *VariantService) GetBySKU(ctx context.Context, sku string) (*model.SKULookupResponse, error) {
+	variant, err := s.repo.GetBySKU(ctx, sku)
+	if err != nil || variant == nil {
+		return nil, err
+	}
+
+	product, err := s.products.GetByID(ctx, variant.ProductID)
+	if err != nil || product == nil {
+		return nil, err
+	}
+
+	for i := range product.Variants {
+		if product.Variants[i].ID == variant.ID {
+			return &model.SKULookupResponse{
+				Product: product,
+				Variant: &product.Variants[i],
+			}, nil
+		}
+	}
+
+	// The cached product predates the variant; fall back to the database row
+	parent, err := s.productRepo.GetByID(ctx, variant.ProductID)
+	if err != nil || parent == nil {
+		return nil, err
+	}
+
+	return &model.SKULookupResponse{
+		Product: toProductResponse(parent),
+		Variant: toVariantResponse(parent, variant),
+	}, nil
+}
+
+// Create adds a variant to a product, or returns nil if the product does not exist
+func (s *VariantService) Create(ctx context.Context, productID int, req *model.VariantRequest) (*model.VariantResponse, error) {
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepo.GetByID(db.WithPrimary(ctx), productID)
+	if err != nil || product == nil {
+		return nil, err
+	}
+
+	variant := &model.ProductVariant{ProductID: productID}
+	if err := s.apply(ctx, product, variant, req); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.Create(ctx, variant); err != nil {
+		return nil, err
+	}
+
+	s.products.invalidateProduct(ctx, productID)
+
+	return toVariantResponse(product, variant), nil
+}
+
+// Update replaces a variant, or returns nil if the product or variant does not exist
+func (s *VariantService) Update(ctx context.Context, productID, id int, req *model.VariantRequest) (*model.VariantResponse, error) {
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepo.GetByID(db.WithPrimary(ctx), productID)
+	if err != nil || product == nil {
+		return nil, err
+	}
+
+	variant := findVariant(product, id)
+	if variant == nil {
+		return nil, nil
+	}
+
+	if err := s.apply(ctx, product, variant, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, id, variant); err != nil {
+		return nil, err
+	}
+
+	s.products.invalidateProduct(ctx, productID)
+
+	return toVariantResponse(product, variant), nil
+}
+
+func (s *VariantService) Delete(ctx context.Context, productID, id int) error {
+	if err := s.repo.Delete(ctx, productID, id); err != nil {
+		return err
+	}
+
+	s.products.invalidateProduct(ctx, productID)
+
+	return nil
+}
+
+// apply validates req against the product's options and sibling variants and copies it into variant
+func (s *VariantService) apply(ctx context.Context, product *model.Product, variant *model.ProductVariant, req *model.VariantRequest) error {
+	existing, err := s.repo.GetBySKU(ctx, req.SKU)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != variant.ID {
+		return validation.Errors{{Field: "sku", Code: "taken", Message: "is already used by another variant"}}
+	}
+
+	if req.Price != nil && !money.FitsScale(*req.Price, product.Currency) {
+		return validation.Errors{{Field: "price", Code: "too_precise", Message: "has more decimal places than the currency allows"}}
+	}
+
+	if errs := checkOptionValues(product.Options, req.OptionValues); len(errs) > 0 {
+		return errs
+	}
+
+	key := optionValuesKey(req.OptionValues)
+	for _, sibling := range product.Variants {
+		if sibling.ID != variant.ID && len(req.OptionValues) > 0 && optionValuesKey(sibling.OptionValues) == key {
+			return validation.Errors{{
+				Field:   "option_values",
+				Code:    "duplicate",
+				Message: fmt.Sprintf("is already used by variant %s", sibling.SKU),
+			}}
+		}
+	}
+
+	variant.SKU = req.SKU
+	variant.Price = decimal.NullDecimal{}
+	if req.Price != nil {
+		variant.Price = decimal.NewNullDecimal(*req.Price)
+	}
+	variant.OptionValues = req.OptionValues
+	if variant.OptionValues == nil {
+		variant.OptionValues = map[string]string{}
+	}
+	variant.Attributes = req.Attributes
+	if variant.Attributes == nil {
+		variant.Attributes = map[string]string{}
+	}
+
+	return nil
+}
+
+// checkOptionValues requires exactly one allowed value for each of the product's options
+func checkOptionValues(options []model.ProductOption, values map[string]string) validation.Errors {
+	var errs validation.Errors
+
+	defined := make(map[string]bool, len(options))
+	for _, option := range options {
+		defined[option.Name] = true
+
+		value, ok := values[option.Name]
+		if !ok {
+			errs = append(errs, validation.FieldError{
+				Field:   "option_values." + option.Name,
+				Code:    "required",
+				Message: "is required",
+			})
+			continue
+		}
+
+		if !slices.Contains(option.Values, value) {
+			errs = append(errs, validation.FieldError{
+				Field:   "option_values." + option.Name,
+				Code:    "invalid_value",
+				Message: "must be one of: " + strings.Join(option.Values, ", "),
+			})
+		}
+	}
+
+	for name := range values {
+		if !defined[name] {
+			errs = append(errs, validation.FieldError{
+				Field:   "option_values." + name,
+				Code:    "unknown_option",
+				Message: "is not an option of the product",
+			})
+		}
+	}
+
+	return errs
+}
+
+// optionValuesKey returns a canonical representation of an option combination
+func optionValuesKey(values map[string]string) string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(values[name])
+		b.WriteByte(';')
+	}
+	return b.String()
+}
+
+func findVariant(product *model.Product, id int) *model.ProductVariant {
+	for i := range product.Variants {
+		if product.Variants[i].ID == id {
+			return &product.Variants[i]
+		}
+	}
+	return nil
+}
+
+func toVariantResponse(product *model.Product, variant *model.ProductVariant) *model.VariantResponse {
+	price := product.Price
+	if variant.Price.Valid {
+		price = variant.Price.Decimal
+	}
+
+	return &model.VariantResponse{
+		ID:            variant.ID,
+		ProductID:     variant.ProductID,
+		SKU:           variant.SKU,
+		Price:         price,
+		Currency:      product.Currency,
+		PriceOverride: variant.Price.Valid,
+		OptionValues:  variant.OptionValues,
+		Attributes:    variant.Attributes,
+		CreatedAt:     variant.CreatedAt,
+		UpdatedAt:     variant.UpdatedAt,
+	}
+}
@@ -0,0 +1,232 @@
+package validation
+
+import (
+	"errors"
+	"product-crud/pkg/money"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+// FieldError describes a single rule violation on a request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors is the list of violations returned when a payload fails validation
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, fe := range e {
+		parts = append(parts, fe.Field+": "+fe.Message)
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+
+	_ = v.RegisterValidation("notblank", notBlank)
+	_ = v.RegisterValidation("slug", slug)
+	_ = v.RegisterValidation("sku", sku)
+	_ = v.RegisterValidation("positive", positive)
+	_ = v.RegisterValidation("currencyscale", currencyScale)
+	_ = v.RegisterValidation("iso4217", iso4217)
+
+	return v
+}
+
+// Struct validates v against its `validate` tags and returns Errors if any rule fails
+func Struct(v interface{}) error {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+
+	result := make(Errors, 0, len(verrs))
+	for _, fe := range verrs {
+		result = append(result, FieldError{
+			Field:   fieldPath(fe),
+			Code:    code(fe),
+			Message: message(fe),
+		})
+	}
+	return result
+}
+
+// fieldPath returns the JSON path of the field without the root struct name, e.g. "prices[1].amount"
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.IndexByte(ns, '.'); i >= 0 {
+		return ns[i+1:]
+	}
+	return fe.Field()
+}
+
+func notBlank(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	if field.Kind() != reflect.String {
+		return true
+	}
+	return strings.TrimSpace(field.String()) != ""
+}
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+func slug(fl validator.FieldLevel) bool {
+	return slugPattern.MatchString(fl.Field().String())
+}
+
+var skuPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+func sku(fl validator.FieldLevel) bool {
+	return skuPattern.MatchString(fl.Field().String())
+}
+
+func positive(fl validator.FieldLevel) bool {
+	amount, ok := fl.Field().Interface().(decimal.Decimal)
+	if !ok {
+		return false
+	}
+	return amount.IsPositive()
+}
+
+// currencyCodes checks codes against the validator's own ISO-4217 list
+var currencyCodes = validator.New()
+
+// iso4217 replaces the built-in rule to accept codes in any case, since
+// money.NormalizeCurrency upper-cases them before they are stored
+func iso4217(fl validator.FieldLevel) bool {
+	code := strings.ToUpper(strings.TrimSpace(fl.Field().String()))
+	return currencyCodes.Var(code, "iso4217") == nil
+}
+
+// currencyScale checks that the amount has no more decimal places than the currency named by the
+// sibling field given as param
+func currencyScale(fl validator.FieldLevel) bool {
+	amount, ok := fl.Field().Interface().(decimal.Decimal)
+	if !ok {
+		return false
+	}
+
+	currency := money.DefaultCurrency
+	if f := fl.Parent().FieldByName(fl.Param()); f.IsValid() && f.Kind() == reflect.String && f.String() != "" {
+		currency = f.String()
+	}
+	return money.FitsScale(amount, currency)
+}
+
+func code(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "required"
+	case "notblank":
+		return "blank"
+	case "positive":
+		return "too_small"
+	case "max":
+		return "too_long"
+	case "gt", "gte", "gtfield":
+		return "out_of_range"
+	case "slug":
+		return "invalid_slug"
+	case "oneof":
+		return "invalid_value"
+	case "sku":
+		return "invalid_sku"
+	case "min":
+		return "too_short"
+	case "currencyscale":
+		return "too_precise"
+	case "iso4217":
+		return "invalid_currency"
+	case "unique":
+		return "duplicate"
+	default:
+		return fe.Tag()
+	}
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "notblank":
+		return "must not be blank"
+	case "positive":
+		return "must be greater than 0"
+	case "max":
+		if fe.Kind() == reflect.Slice || fe.Kind() == reflect.Map {
+			return "must contain at most " + fe.Param() + " items"
+		}
+		return "must be at most " + fe.Param() + " characters long"
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be at least " + fe.Param()
+	case "gtfield":
+		return "must be after " + snakeCase(fe.Param())
+	case "slug":
+		return "must contain only lower-case letters, digits and single hyphens"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "sku":
+		return "must start with a letter or digit and contain only letters, digits, '.', '_' and '-'"
+	case "min":
+		if fe.Kind() == reflect.Slice || fe.Kind() == reflect.Map {
+			return "must contain at least " + fe.Param() + " items"
+		}
+		return "must be at least " + fe.Param() + " characters long"
+	case "currencyscale":
+		return "has more decimal places than the currency allows"
+	case "iso4217":
+		return "must be an ISO-4217 currency code"
+	case "unique":
+		if fe.Param() == "" {
+			return "must not contain duplicate values"
+		}
+		return "must not contain duplicate " + strings.ToLower(fe.Param()) + " values"
+	default:
+		return "failed the " + fe.Tag() + " rule"
+	}
+}
+
+// snakeCase turns a Go field name such as EffectiveFrom into its JSON name effective_from
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
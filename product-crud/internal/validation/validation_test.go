@@ -0,0 +1,214 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+type priceRequest struct {
+	Price    decimal.Decimal `json:"price" validate:"positive,currencyscale=Currency"`
+	Currency string          `json:"currency,omitempty" validate:"omitempty,iso4217"`
+}
+
+type productRequest struct {
+	Name   string         `json:"name" validate:"required,notblank,max=10"`
+	Slug   string         `json:"slug,omitempty" validate:"omitempty,slug"`
+	SKU    string         `json:"sku,omitempty" validate:"omitempty,sku"`
+	Prices []priceRequest `json:"prices,omitempty" validate:"omitempty,max=3,unique=Currency,dive"`
+	Hidden string         `json:"-" validate:"omitempty,oneof=yes no"`
+}
+
+// codes returns the code of each field error of err, by field
+func codes(t *testing.T, err error) map[string]string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Struct returned %v, want Errors", err)
+	}
+	result := make(map[string]string, len(errs))
+	for _, fe := range errs {
+		result[fe.Field] = fe.Code
+	}
+	return result
+}
+
+func TestPositive(t *testing.T) {
+	tests := []struct {
+		price string
+		valid bool
+	}{
+		{price: "0.01", valid: true},
+		{price: "0", valid: false},
+		{price: "-1", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.price, func(t *testing.T) {
+			got := codes(t, Struct(&priceRequest{Price: decimal.RequireFromString(tt.price)}))
+			if tt.valid && len(got) > 0 {
+				t.Errorf("price %s rejected: %v", tt.price, got)
+			}
+			if !tt.valid && got["price"] != "too_small" {
+				t.Errorf("price %s: errors %v, want price too_small", tt.price, got)
+			}
+		})
+	}
+}
+
+func TestISO4217(t *testing.T) {
+	tests := []struct {
+		currency string
+		valid    bool
+	}{
+		{currency: "USD", valid: true},
+		{currency: "usd", valid: true},
+		{currency: "Eur", valid: true},
+		{currency: " jpy ", valid: true},
+		{currency: "XYZ", valid: false},
+		{currency: "US", valid: false},
+		{currency: "dollars", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.currency, func(t *testing.T) {
+			got := codes(t, Struct(&priceRequest{Price: decimal.NewFromInt(1), Currency: tt.currency}))
+			if tt.valid && len(got) > 0 {
+				t.Errorf("currency %q rejected: %v", tt.currency, got)
+			}
+			if !tt.valid && got["currency"] != "invalid_currency" {
+				t.Errorf("currency %q: errors %v, want currency invalid_currency", tt.currency, got)
+			}
+		})
+	}
+}
+
+func TestCurrencyScale(t *testing.T) {
+	tests := []struct {
+		name     string
+		price    string
+		currency string
+		valid    bool
+	}{
+		{name: "cents", price: "19.99", currency: "USD", valid: true},
+		{name: "too precise", price: "19.999", currency: "USD", valid: false},
+		{name: "default currency", price: "19.999", currency: "", valid: false},
+		{name: "three digit currency", price: "1.999", currency: "BHD", valid: true},
+		{name: "lower-case currency", price: "1.999", currency: "bhd", valid: true},
+		{name: "no minor unit", price: "1999.5", currency: "JPY", valid: false},
+		{name: "no minor unit, padded", price: "1999.5", currency: " jpy ", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := codes(t, Struct(&priceRequest{Price: decimal.RequireFromString(tt.price), Currency: tt.currency}))
+			if tt.valid && len(got) > 0 {
+				t.Errorf("%s %s rejected: %v", tt.price, tt.currency, got)
+			}
+			if !tt.valid && got["price"] != "too_precise" {
+				t.Errorf("%s %s: errors %v, want price too_precise", tt.price, tt.currency, got)
+			}
+		})
+	}
+}
+
+func TestStringRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   productRequest
+		field string
+		code  string
+	}{
+		{name: "missing name", req: productRequest{}, field: "name", code: "required"},
+		{name: "blank name", req: productRequest{Name: "   "}, field: "name", code: "blank"},
+		{name: "long name", req: productRequest{Name: "a name too long"}, field: "name", code: "too_long"},
+		{name: "upper-case slug", req: productRequest{Name: "n", Slug: "Shoes"}, field: "slug", code: "invalid_slug"},
+		{name: "double hyphen slug", req: productRequest{Name: "n", Slug: "red--shoes"}, field: "slug", code: "invalid_slug"},
+		{name: "sku starting with a dot", req: productRequest{Name: "n", SKU: ".SHOE-1"}, field: "sku", code: "invalid_sku"},
+		{name: "sku with a space", req: productRequest{Name: "n", SKU: "SHOE 1"}, field: "sku", code: "invalid_sku"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := codes(t, Struct(&tt.req))
+			if got[tt.field] != tt.code {
+				t.Errorf("errors %v, want %s %s", got, tt.field, tt.code)
+			}
+		})
+	}
+
+	if err := Struct(&productRequest{Name: "n", Slug: "red-shoes", SKU: "SHOE_1.v2"}); err != nil {
+		t.Errorf("valid request rejected: %v", err)
+	}
+}
+
+func TestErrorShape(t *testing.T) {
+	err := Struct(&productRequest{
+		Name: "shoes",
+		Prices: []priceRequest{
+			{Price: decimal.RequireFromString("1"), Currency: "USD"},
+			{Price: decimal.RequireFromString("1.001"), Currency: "EUR"},
+		},
+		Hidden: "maybe",
+	})
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Struct returned %v, want Errors", err)
+	}
+
+	want := Errors{
+		{Field: "prices[1].price", Code: "too_precise", Message: "has more decimal places than the currency allows"},
+		{Field: "Hidden", Code: "invalid_value", Message: "must be one of: yes, no"},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("errors = %+v, want %+v", errs, want)
+	}
+	for i := range want {
+		if errs[i] != want[i] {
+			t.Errorf("errors[%d] = %+v, want %+v", i, errs[i], want[i])
+		}
+	}
+
+	wantText := "validation failed: prices[1].price: has more decimal places than the currency allows; Hidden: must be one of: yes, no"
+	if errs.Error() != wantText {
+		t.Errorf("Error() = %q, want %q", errs.Error(), wantText)
+	}
+
+	encoded, _ := json.Marshal(errs[0])
+	if string(encoded) != `{"field":"prices[1].price","code":"too_precise","message":"has more decimal places than the currency allows"}` {
+		t.Errorf("JSON = %s", encoded)
+	}
+}
+
+func TestErrorShapeOfListRules(t *testing.T) {
+	prices := []priceRequest{
+		{Price: decimal.NewFromInt(1), Currency: "USD"},
+		{Price: decimal.NewFromInt(2), Currency: "USD"},
+	}
+	err := Struct(&productRequest{Name: "shoes", Prices: prices})
+
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("Struct returned %v, want one error", err)
+	}
+	want := FieldError{Field: "prices", Code: "duplicate", Message: "must not contain duplicate currency values"}
+	if errs[0] != want {
+		t.Errorf("error = %+v, want %+v", errs[0], want)
+	}
+
+	prices = append(prices, prices[0], prices[1])
+	err = Struct(&productRequest{Name: "shoes", Prices: prices})
+	if got := codes(t, err); got["prices"] != "too_long" {
+		t.Errorf("errors %v, want prices too_long", got)
+	}
+	if errors.As(err, &errs) && errs[0].Message != "must contain at most 3 items" {
+		t.Errorf("message = %q, want %q", errs[0].Message, "must contain at most 3 items")
+	}
+}
@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// bloomCheckScript reports whether every bit at the given offsets is set. A missing
+// filter, for example one evicted or not built yet, answers 1 so nothing is rejected.
+var bloomCheckScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  return 1
+end
+for _, offset in ipairs(ARGV) do
+  if redis.call('GETBIT', KEYS[1], offset) == 0 then
+    return 0
+  end
+end
+return 1
+`)
+
+// bloomAddScript sets the bits of the items, unless the filter is missing: setting them
+// would create a filter holding only these items, which rejects every other one. It
+// returns whether the filter exists.
+var bloomAddScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  return 0
+end
+for _, offset in ipairs(ARGV) do
+  redis.call('SETBIT', KEYS[1], offset, 1)
+end
+return 1
+`)
+
+// BloomFilter is a set membership filter stored as a Redis bitmap, shared by every
+// instance. It can answer "maybe present" for an absent item, never the reverse, so it
+// only ever saves lookups of items that certainly do not exist.
+type BloomFilter struct {
+	rc     *RedisCache
+	client redis.UniversalClient
+	name   string
+	key    string
+	bits   uint64
+	hashes int
+}
+
+// NewBloomFilter sizes a filter for capacity items at the given false positive rate
+func NewBloomFilter(rc *RedisCache, key string, capacity int, falsePositiveRate float64) *BloomFilter {
+	n := float64(max(capacity, 1))
+	bits := math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	hashes := int(math.Round(bits / n * math.Ln2))
+
+	return &BloomFilter{
+		rc:     rc,
+		client: rc.client,
+		name:   key,
+		key:    rc.key(key),
+		bits:   uint64(bits),
+		hashes: max(hashes, 1),
+	}
+}
+
+// Add records items in the filter. It reports false, changing nothing, when the filter
+// is missing, for example after an eviction or a flush; the caller should rebuild it.
+func (b *BloomFilter) Add(ctx context.Context, items ...string) (bool, error) {
+	if len(items) == 0 {
+		return true, nil
+	}
+
+	var args []interface{}
+	for _, item := range items {
+		for _, offset := range b.offsets(item) {
+			args = append(args, offset)
+		}
+	}
+
+	exists, err := bloomAddScript.Run(ctx, b.client, []string{b.key}, args...).Int()
+	if err != nil {
+		return false, err
+	}
+	return exists == 1, nil
+}
+
+// Drop removes the filter, so lookups let everything through until it is rebuilt. For
+// use when an item could not be added: the filter would reject it otherwise. If Redis
+// cannot be reached, the removal is replayed once it can.
+func (b *BloomFilter) Drop(ctx context.Context) error {
+	return b.rc.Delete(ctx, b.name)
+}
+
+// MightContain reports whether item may have been added. It answers true when the
+// filter cannot be consulted, so an outage never hides existing items.
+func (b *BloomFilter) MightContain(ctx context.Context, item string) (bool, error) {
+	offsets := b.offsets(item)
+	args := make([]interface{}, len(offsets))
+	for i, offset := range offsets {
+		args[i] = offset
+	}
+
+	found, err := bloomCheckScript.Run(ctx, b.client, []string{b.key}, args...).Int()
+	if err != nil {
+		return true, err
+	}
+	return found == 1, nil
+}
+
+// Rebuild replaces the filter with one holding exactly items. The bitmap is built in
+// memory and swapped in atomically, so readers never see a partial filter. Items added
+// between reading them and the swap are lost; callers add those again afterwards.
+func (b *BloomFilter) Rebuild(ctx context.Context, items []string) error {
+	bitmap := make([]byte, (b.bits+7)/8)
+	for _, item := range items {
+		for _, offset := range b.offsets(item) {
+			// Redis numbers bits from the most significant bit of the first byte
+			bitmap[offset/8] |= 0x80 >> (offset % 8)
+		}
+	}
+
+	// RENAME needs both keys in one hash slot on a cluster. A key without a hash tag is
+	// hashed whole, so wrapping it in one keeps the slot.
+	tmp := b.key + ":rebuild"
+	if !strings.Contains(b.key, "{") {
+		tmp = "{" + b.key + "}:rebuild"
+	}
+	_, err := b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, tmp, bitmap, 0)
+		pipe.Rename(ctx, tmp, b.key)
+		return nil
+	})
+	return err
+}
+
+// offsets derives the item's bit positions by double hashing
+func (b *BloomFilter) offsets(item string) []uint64 {
+	h1 := fnv.New64a()
+	h1.Write([]byte(item))
+	sum1 := h1.Sum64()
+
+	h2 := fnv.New64()
+	h2.Write([]byte(item))
+	// Keep the step non-zero so the positions differ
+	sum2 := h2.Sum64() | 1
+
+	offsets := make([]uint64, b.hashes)
+	for i := range offsets {
+		offsets[i] = (sum1 + uint64(i)*sum2) % b.bits
+	}
+	return offsets
+}
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestBloomFilter(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	filter := NewBloomFilter(newTestCache(t, server), "bloom:ids", 1000, 0.01)
+
+	// A missing filter must stay missing: holding only the new item, it would reject
+	// every item added before it was lost
+	exists, err := filter.Add(ctx, "1")
+	if err != nil || exists {
+		t.Fatalf("Add to a missing filter = %t, %v; want false, nil", exists, err)
+	}
+	if server.Exists("bloom:ids") {
+		t.Fatal("Add created the missing filter")
+	}
+	if maybe, err := filter.MightContain(ctx, "42"); err != nil || !maybe {
+		t.Fatalf("MightContain on a missing filter = %t, %v; want true, nil", maybe, err)
+	}
+
+	var items []string
+	for i := 1; i <= 100; i++ {
+		items = append(items, strconv.Itoa(i))
+	}
+	mustSucceed(t, filter.Rebuild(ctx, items))
+
+	exists, err = filter.Add(ctx, "101")
+	if err != nil || !exists {
+		t.Fatalf("Add to a built filter = %t, %v; want true, nil", exists, err)
+	}
+
+	for _, item := range append(items, "101") {
+		if maybe, err := filter.MightContain(ctx, item); err != nil || !maybe {
+			t.Fatalf("MightContain(%s) = %t, %v; want true, nil", item, maybe, err)
+		}
+	}
+
+	rejected := 0
+	for i := 1000; i < 1100; i++ {
+		if maybe, _ := filter.MightContain(ctx, strconv.Itoa(i)); !maybe {
+			rejected++
+		}
+	}
+	if rejected < 90 {
+		t.Errorf("rejected %d of 100 absent items, want nearly all", rejected)
+	}
+
+	mustSucceed(t, filter.Drop(ctx))
+	if maybe, err := filter.MightContain(ctx, "5000"); err != nil || !maybe {
+		t.Fatalf("MightContain after Drop = %t, %v; want true, nil", maybe, err)
+	}
+}
@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"product-crud/pkg/logger"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sony/gobreaker/v2"
+)
+
+// ErrUnavailable is returned, without contacting Redis, while the circuit breaker is open
+var ErrUnavailable = errors.New("cache: redis unavailable")
+
+// BreakerConfig configures the circuit breaker in front of Redis
+type BreakerConfig struct {
+	// Failures is how many consecutive failed commands open the breaker
+	Failures uint32
+	// OpenTimeout is how long the breaker stays open before letting probes through
+	OpenTimeout time.Duration
+	// Probes is how many commands may run while half-open; they must all succeed to close it
+	Probes uint32
+}
+
+// BreakerStats describes the circuit breaker
+type BreakerStats struct {
+	// State is closed, open or half-open
+	State               string `json:"state"`
+	ConsecutiveFailures uint32 `json:"consecutive_failures"`
+}
+
+// breakerHook runs every command through a circuit breaker, so that once Redis is
+// found down callers get ErrUnavailable at once instead of waiting for a timeout
+type breakerHook struct {
+	cb *gobreaker.TwoStepCircuitBreaker[struct{}]
+}
+
+func newBreakerHook(cfg BreakerConfig, onStateChange func(from, to gobreaker.State)) *breakerHook {
+	return &breakerHook{cb: gobreaker.NewTwoStepCircuitBreaker[struct{}](gobreaker.Settings{
+		Name:        "redis",
+		MaxRequests: cfg.Probes,
+		Timeout:     cfg.OpenTimeout,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= max(cfg.Failures, 1)
+		},
+		OnStateChange: func(_ string, from, to gobreaker.State) {
+			onStateChange(from, to)
+		},
+		IsSuccessful: reachable,
+		IsExcluded: func(err error) bool {
+			return errors.Is(err, context.Canceled)
+		},
+	})}
+}
+
+func (h *breakerHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *breakerHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		done, err := h.cb.Allow()
+		if err != nil {
+			cmd.SetErr(ErrUnavailable)
+			return ErrUnavailable
+		}
+
+		err = next(ctx, cmd)
+		done(err)
+		return err
+	}
+}
+
+func (h *breakerHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		done, err := h.cb.Allow()
+		if err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(ErrUnavailable)
+			}
+			return ErrUnavailable
+		}
+
+		err = next(ctx, cmds)
+		done(err)
+		return err
+	}
+}
+
+func (h *breakerHook) stats() BreakerStats {
+	return BreakerStats{
+		State:               h.cb.State().String(),
+		ConsecutiveFailures: h.cb.Counts().ConsecutiveFailures,
+	}
+}
+
+// reachable reports whether a command that returned err reached Redis. Error replies,
+// such as a missing key or script, show that Redis is up.
+func reachable(err error) bool {
+	if err == nil {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return false
+	}
+
+	var replyErr redis.Error
+	return errors.As(err, &replyErr)
+}
+
+// Breaker returns the state of the circuit breaker in front of Redis
+func (rc *RedisCache) Breaker() BreakerStats {
+	return rc.breaker.stats()
+}
+
+// onBreakerStateChange logs transitions. Once the breaker closes again the local cache
+// is purged, as invalidations from other instances were lost while Redis was down.
+func (rc *RedisCache) onBreakerStateChange(from, to gobreaker.State) {
+	rc.logger.Warn("Redis circuit breaker changed state", logger.String("from", from.String()), logger.String("to", to.String()))
+
+	if to == gobreaker.StateClosed {
+		rc.local.purge()
+	}
+}
+
+// StartHealthCheck pings Redis every interval until ctx is cancelled. The pings probe an
+// open breaker, so caching resumes when Redis comes back even without traffic, and
+// once Redis answers the invalidations it missed are replayed.
+func (rc *RedisCache) StartHealthCheck(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := rc.client.Ping(ctx).Err(); err != nil {
+					continue
+				}
+				if err := rc.replayInvalidations(ctx); err != nil {
+					rc.logger.Error("Failed to replay missed cache invalidations", logger.Err(err))
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,242 @@
+package cache
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Deployments the cache can talk to
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+)
+
+var modes = []string{ModeStandalone, ModeSentinel, ModeCluster}
+
+// TLSConfig enables TLS towards Redis. CACert verifies the server instead of the system
+// roots; Cert and Key are the client certificate, for servers requiring one.
+type TLSConfig struct {
+	Enabled    bool
+	CACert     string
+	Cert       string
+	Key        string
+	ServerName string
+}
+
+// newClient builds the client for the deployment cfg describes, without connecting yet
+func newClient(cfg Config) (redis.UniversalClient, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	opts, err := cfg.options()
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.mode() {
+	case ModeSentinel:
+		return redis.NewFailoverClient(opts.Failover()), nil
+	case ModeCluster:
+		return redis.NewClusterClient(opts.Cluster()), nil
+	default:
+		return redis.NewClient(opts.Simple()), nil
+	}
+}
+
+func (cfg Config) mode() string {
+	if cfg.Mode == "" {
+		return ModeStandalone
+	}
+	return cfg.Mode
+}
+
+func (cfg Config) validate() error {
+	if !slices.Contains(modes, cfg.mode()) {
+		return fmt.Errorf("invalid Redis mode %q, want one of %s", cfg.Mode, strings.Join(modes, ", "))
+	}
+
+	switch cfg.mode() {
+	case ModeSentinel:
+		if cfg.URL != "" {
+			return errors.New("a Redis URL cannot describe a Sentinel deployment, use the master name and addresses")
+		}
+		if cfg.MasterName == "" {
+			return errors.New("Redis Sentinel needs a master name")
+		}
+	case ModeCluster:
+		if cfg.DB != 0 {
+			return errors.New("Redis Cluster only has database 0")
+		}
+	}
+	if cfg.URL == "" && len(cfg.Addrs) == 0 {
+		return errors.New("no Redis address configured")
+	}
+
+	// The prefix is part of every SCAN pattern, where these would be wildcards
+	if strings.ContainsAny(cfg.KeyPrefix, `*?[]\`) {
+		return fmt.Errorf("invalid key prefix %q: it may not contain glob characters", cfg.KeyPrefix)
+	}
+
+	for _, file := range []string{cfg.TLS.CACert, cfg.TLS.Cert, cfg.TLS.Key} {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Errorf("TLS file: %w", err)
+		}
+	}
+	if (cfg.TLS.Cert == "") != (cfg.TLS.Key == "") {
+		return errors.New("a TLS client certificate needs both a certificate and a key file")
+	}
+
+	return nil
+}
+
+// options merges the URL, if any, with the structured settings, which take precedence
+// where both are set
+func (cfg Config) options() (*redis.UniversalOptions, error) {
+	opts := &redis.UniversalOptions{
+		Addrs:            cfg.Addrs,
+		MasterName:       cfg.MasterName,
+		Username:         cfg.Username,
+		Password:         cfg.Password,
+		SentinelPassword: cfg.SentinelPassword,
+		DB:               cfg.DB,
+		PoolSize:         cfg.PoolSize,
+		MinIdleConns:     cfg.MinIdleConns,
+		DialTimeout:      cfg.OperationTimeout,
+		ReadTimeout:      cfg.OperationTimeout,
+		WriteTimeout:     cfg.OperationTimeout,
+		PoolTimeout:      cfg.OperationTimeout,
+		// Retrying a command that timed out would multiply the wait; the breaker
+		// decides when Redis is worth trying again
+		MaxRetries: -1,
+	}
+
+	if cfg.URL != "" {
+		if err := cfg.applyURL(opts); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.TLS.Enabled || cfg.TLS.CACert != "" || cfg.TLS.Cert != "" {
+		tlsConfig, err := cfg.TLS.build(opts.TLSConfig)
+		if err != nil {
+			return nil, err
+		}
+		opts.TLSConfig = tlsConfig
+	}
+
+	return opts, nil
+}
+
+// applyURL fills opts from a redis:// or rediss:// URL; in cluster mode further nodes
+// are given as addr query parameters
+func (cfg Config) applyURL(opts *redis.UniversalOptions) error {
+	var (
+		addrs              []string
+		username, password string
+		db                 int
+		tlsConfig          *tls.Config
+	)
+
+	if cfg.mode() == ModeCluster {
+		parsed, err := redis.ParseClusterURL(cfg.URL)
+		if err != nil {
+			return fmt.Errorf("invalid Redis URL: %w", err)
+		}
+		addrs, username, password, tlsConfig = parsed.Addrs, parsed.Username, parsed.Password, parsed.TLSConfig
+	} else {
+		parsed, err := redis.ParseURL(cfg.URL)
+		if err != nil {
+			return fmt.Errorf("invalid Redis URL: %w", err)
+		}
+		addrs, username, password, tlsConfig = []string{parsed.Addr}, parsed.Username, parsed.Password, parsed.TLSConfig
+		db = parsed.DB
+	}
+
+	opts.TLSConfig = tlsConfig
+	if len(opts.Addrs) == 0 {
+		opts.Addrs = addrs
+	}
+	if opts.Username == "" {
+		opts.Username = username
+	}
+	if opts.Password == "" {
+		opts.Password = password
+	}
+	if opts.DB == 0 {
+		opts.DB = db
+	}
+
+	return nil
+}
+
+// build completes base, the TLS settings of the URL if any, with the certificates
+func (t TLSConfig) build(base *tls.Config) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if base != nil {
+		config = base.Clone()
+	}
+	if t.ServerName != "" {
+		config.ServerName = t.ServerName
+	}
+
+	if t.CACert != "" {
+		pem, err := os.ReadFile(t.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("TLS CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("TLS CA certificate: no certificate found in %s", t.CACert)
+		}
+		config.RootCAs = pool
+	}
+
+	if t.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(t.Cert, t.Key)
+		if err != nil {
+			return nil, fmt.Errorf("TLS client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// forEachMaster calls fn with the client of every master: each shard on a cluster, the
+// single server otherwise. On a cluster, commands sent to a shard bypass the breaker.
+func (rc *RedisCache) forEachMaster(ctx context.Context, fn func(ctx context.Context, client *redis.Client) error) error {
+	if cluster, ok := rc.client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, fn)
+	}
+	return fn(ctx, rc.client.(*redis.Client))
+}
+
+// unlink removes keys and returns how many existed. Each key gets its own command, as a
+// multi-key UNLINK fails on a cluster when the keys span hash slots.
+func unlink(ctx context.Context, client redis.Cmdable, keys []string) (int64, error) {
+	pipe := client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Unlink(ctx, key)
+	}
+	_, err := pipe.Exec(ctx)
+
+	var deleted int64
+	for _, cmd := range cmds {
+		deleted += cmd.Val()
+	}
+	return deleted, err
+}
@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	// ttlJitter spreads expirations by up to ±10% of the TTL
+	ttlJitter = 0.1
+	// earlyExpiryBeta scales probabilistic early expiration; above 1 favours earlier refreshes
+	earlyExpiryBeta = 1.0
+)
+
+var errInvalidEntry = errors.New("cache: invalid entry")
+
+// Loader computes the value to cache for a key. A nil value means there is nothing to
+// cache, for example because the record does not exist.
+type Loader func(ctx context.Context) (interface{}, error)
+
+// entry is the stored form of a value. Redis keeps it until the soft expiry plus the
+// stale window, so a stale value can be served while it is being refreshed.
+type entry struct {
+	Value json.RawMessage `json:"v"`
+	// SoftExpiry is when the value becomes stale, in Unix milliseconds
+	SoftExpiry int64 `json:"exp"`
+	// Delta is how long the value took to compute, in milliseconds
+	Delta int64 `json:"delta,omitempty"`
+	// Version orders writes of Versioned values; see casScript
+	Version int64 `json:"ver,omitempty"`
+	// Deleted marks a key known to have no value: a tombstone left by Retire or a miss
+	// remembered by Fetch
+	Deleted bool `json:"del,omitempty"`
+}
+
+// Fetch returns the value of key unmarshalled into dest, calling load on a miss and
+// caching the result. Concurrent misses for a key share a single load. A value past its
+// soft expiry, or picked for early expiration, is still returned while one background
+// load refreshes it. When load finds nothing, that is remembered for the negative TTL
+// and reported without calling load again. Redis errors degrade to calling load.
+func (rc *RedisCache) Fetch(ctx context.Context, key string, dest interface{}, load Loader) (bool, error) {
+	// The local cache only holds fresh entries, so a hit never needs refreshing
+	if e, ok := rc.local.get(key); ok && json.Unmarshal(e.Value, dest) == nil {
+		rc.stats.localHit(key)
+		return true, nil
+	}
+
+	data, err := rc.client.Get(ctx, rc.key(key)).Bytes()
+	if err == nil {
+		e, err := decodeEntry(data)
+		if err == nil && e.Deleted {
+			// A remembered miss spares the load, so it counts as a hit
+			rc.stats.hit(key)
+			return false, nil
+		}
+		if err == nil && json.Unmarshal(e.Value, dest) == nil {
+			rc.stats.hit(key)
+			if e.shouldRefresh(time.Now()) {
+				// DoChan joins a refresh already in flight; its result only matters to Redis
+				rc.group.DoChan(key, func() (interface{}, error) {
+					return rc.load(context.WithoutCancel(ctx), key, load)
+				})
+			} else {
+				rc.local.set(key, e)
+			}
+			return true, nil
+		}
+	}
+
+	rc.stats.miss(key)
+
+	// The load is shared, so it must not be cut short by the first caller going away
+	ch := rc.group.DoChan(key, func() (interface{}, error) {
+		return rc.load(context.WithoutCancel(ctx), key, load)
+	})
+
+	select {
+	case result := <-ch:
+		if result.Err != nil {
+			return false, result.Err
+		}
+		value, ok := result.Val.([]byte)
+		if !ok {
+			return false, nil
+		}
+		return true, json.Unmarshal(value, dest)
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// load runs the loader and caches what it returns, recording how long it took
+func (rc *RedisCache) load(ctx context.Context, key string, load Loader) (interface{}, error) {
+	start := time.Now()
+
+	value, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		rc.rememberMiss(ctx, key)
+		return nil, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	// A failed write only costs the next reader another load, and a rejected one means a
+	// newer version is already cached
+	_, _ = rc.write(ctx, key, data, versionOf(value), rc.ttl, time.Since(start))
+
+	return data, nil
+}
+
+// rememberMiss caches that key has no value for the negative TTL. The marker ranks below
+// every real version, so it never hides a value written concurrently and any write of
+// the key replaces it.
+func (rc *RedisCache) rememberMiss(ctx context.Context, key string) {
+	if rc.negativeTTL <= 0 {
+		return
+	}
+
+	encoded, err := json.Marshal(entry{Value: json.RawMessage("null"), Version: missVersion, Deleted: true})
+	if err != nil {
+		return
+	}
+
+	// Like a failed fill, a failed write only costs the next reader another load
+	_, _ = rc.store(ctx, key, encoded, missVersion, rc.negativeTTL)
+}
+
+// write stores data in Redis and the local cache, unless Redis already holds a newer
+// version, and reports whether it was stored
+func (rc *RedisCache) write(ctx context.Context, key string, data []byte, version int64, ttl, delta time.Duration) (bool, error) {
+	e, encoded, expiration, err := rc.encode(data, version, ttl, delta)
+	if err != nil {
+		return false, err
+	}
+
+	stored, err := rc.store(ctx, key, encoded, version, expiration)
+	if err != nil || !stored {
+		rc.local.delete(key)
+		return false, err
+	}
+
+	rc.local.set(key, e)
+	return true, nil
+}
+
+// encode wraps data in an entry that goes stale after a jittered ttl, and returns how
+// long Redis should keep it: staleTTL past that. A non-positive ttl never expires.
+func (rc *RedisCache) encode(data []byte, version int64, ttl, delta time.Duration) (entry, []byte, time.Duration, error) {
+	e := entry{Value: data, Delta: delta.Milliseconds(), Version: version}
+
+	expiration := time.Duration(0)
+	if ttl > 0 {
+		ttl = jitter(ttl)
+		e.SoftExpiry = time.Now().Add(ttl).UnixMilli()
+		expiration = ttl + rc.staleTTL
+	}
+
+	encoded, err := json.Marshal(e)
+	if err != nil {
+		return entry{}, nil, 0, err
+	}
+
+	return e, encoded, expiration, nil
+}
+
+// shouldRefresh reports whether the entry is stale or was picked for early expiration.
+// The chance of an early pick grows as the soft expiry nears and with how slow the value
+// is to compute (the XFetch algorithm), so a hot key is usually refreshed by one reader
+// before it goes stale.
+func (e entry) shouldRefresh(now time.Time) bool {
+	if e.SoftExpiry == 0 {
+		return false
+	}
+
+	// 1-rand is in (0, 1], keeping the logarithm finite
+	early := -float64(e.Delta) * earlyExpiryBeta * math.Log(1-rand.Float64())
+	return now.UnixMilli()+int64(early) >= e.SoftExpiry
+}
+
+// decodeEntry unwraps a stored entry. Values written before entries were introduced are
+// returned as they are, with no soft expiry.
+func decodeEntry(data []byte) (entry, error) {
+	var e entry
+	if err := json.Unmarshal(data, &e); err == nil && e.Value != nil {
+		return e, nil
+	}
+
+	if !json.Valid(data) {
+		return entry{}, errInvalidEntry
+	}
+	return entry{Value: data}, nil
+}
+
+func jitter(ttl time.Duration) time.Duration {
+	return ttl + time.Duration((rand.Float64()*2-1)*ttlJitter*float64(ttl))
+}
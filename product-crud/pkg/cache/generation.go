@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Generation names the current generation of a group of keys that are invalidated together,
+// such as every listing of a collection. Callers put the generation in the keys they cache;
+// Bump replaces it, so readers move on to new keys and a fill that read its data before the
+// bump can only write a key of an old generation, which nothing reads any more. Generations
+// are random rather than counted, so one is never reused after the name is flushed or evicted.
+type Generation struct {
+	client redis.UniversalClient
+	key    string
+}
+
+func NewGeneration(rc *RedisCache, key string) *Generation {
+	return &Generation{
+		client: rc.client,
+		key:    rc.key(key),
+	}
+}
+
+// Current returns the current generation, starting one if there is none
+func (g *Generation) Current(ctx context.Context) (string, error) {
+	generation, err := g.client.Get(ctx, g.key).Result()
+	if err != redis.Nil {
+		return generation, err
+	}
+
+	// Another instance may start one first; whichever is stored wins
+	if err := g.client.SetNX(ctx, g.key, uuid.NewString(), 0).Err(); err != nil {
+		return "", err
+	}
+	return g.client.Get(ctx, g.key).Result()
+}
+
+// Bump starts a new generation, invalidating every key of the previous ones
+func (g *Generation) Bump(ctx context.Context) error {
+	return g.client.Set(ctx, g.key, uuid.NewString(), 0).Err()
+}
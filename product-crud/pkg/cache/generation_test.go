@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestGeneration(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	writer, reader := newTestCache(t, server), newTestCache(t, server)
+	writerGen, readerGen := NewGeneration(writer, "lists:generation"), NewGeneration(reader, "lists:generation")
+
+	first, err := readerGen.Current(ctx)
+	mustSucceed(t, err)
+	if again, err := writerGen.Current(ctx); err != nil || again != first {
+		t.Fatalf("Current = %q, %v; want %q shared by every instance", again, err, first)
+	}
+
+	// A listing fill that read the database before the invalidation finishes after it
+	mustSucceed(t, writerGen.Bump(ctx))
+	mustSucceed(t, reader.Set(ctx, "lists:"+first, &snapshot{Version: 1}))
+
+	second, err := readerGen.Current(ctx)
+	mustSucceed(t, err)
+	if second == first {
+		t.Fatalf("Current after Bump = %q, want a new generation", second)
+	}
+	var got snapshot
+	if found, err := reader.Get(ctx, "lists:"+second, &got); err != nil || found {
+		t.Fatalf("Get of the new generation = %t, %v; want a miss", found, err)
+	}
+
+	// A flushed or evicted name must not restart at a generation used before
+	server.Del("lists:generation")
+	third, err := readerGen.Current(ctx)
+	mustSucceed(t, err)
+	if third == first || third == second {
+		t.Fatalf("Current after a flush = %q, want a generation not used before", third)
+	}
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// KeyInfo describes what Redis holds for a key
+type KeyInfo struct {
+	Key       string `json:"key"`
+	Namespace string `json:"namespace"`
+	// Type is the Redis type of the key; only strings hold cache entries
+	Type string `json:"type"`
+	// TTL is how long Redis keeps the key, in milliseconds, -1 meaning forever
+	TTL int64 `json:"ttl_ms"`
+	// StaleAt is when the value stops being fresh, if it ever does
+	StaleAt *time.Time `json:"stale_at,omitempty"`
+	Version int64      `json:"version,omitempty"`
+	// Deleted marks a tombstone or a remembered miss, which reads as absent
+	Deleted bool `json:"deleted"`
+	// Local reports whether this instance also holds the key in its local cache
+	Local bool            `json:"local"`
+	Size  int             `json:"size"`
+	Value json.RawMessage `json:"value" swaggertype:"object"`
+}
+
+// Inspect returns what is cached under key, or nil if nothing is. Values that are not
+// cache entries, such as the ID filter bitmap or the request counts, are described by
+// their type and size only.
+func (rc *RedisCache) Inspect(ctx context.Context, key string) (*KeyInfo, error) {
+	pipe := rc.client.Pipeline()
+	typ := pipe.Type(ctx, rc.key(key))
+	ttl := pipe.PTTL(ctx, rc.key(key))
+	get := pipe.Get(ctx, rc.key(key))
+	// GET fails on keys that are not strings; TYPE tells those apart below
+	_, _ = pipe.Exec(ctx)
+
+	if err := typ.Err(); err != nil {
+		return nil, err
+	}
+	if typ.Val() == "none" {
+		return nil, nil
+	}
+
+	info := &KeyInfo{
+		Key:       key,
+		Namespace: Namespace(key),
+		Type:      typ.Val(),
+		TTL:       -1,
+		Local:     rc.local.peek(key),
+	}
+	if d := ttl.Val(); d > 0 {
+		info.TTL = d.Milliseconds()
+	}
+	if info.Type != "string" {
+		return info, nil
+	}
+
+	data, err := get.Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	info.Size = len(data)
+
+	e, err := decodeEntry(data)
+	if err != nil {
+		return info, nil
+	}
+
+	info.Value = e.Value
+	info.Version = e.Version
+	info.Deleted = e.Deleted
+	if e.SoftExpiry != 0 {
+		staleAt := time.UnixMilli(e.SoftExpiry).UTC()
+		info.StaleAt = &staleAt
+	}
+
+	return info, nil
+}
@@ -0,0 +1,181 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"product-crud/pkg/logger"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// invalidationChannel carries the keys every instance must evict from its local cache
+const invalidationChannel = "cache:invalidations"
+
+// invalidationRetryDelay is how long the listener waits after a failed receive
+const invalidationRetryDelay = time.Second
+
+type invalidation struct {
+	// Origin lets an instance skip its own messages; it evicted locally before publishing
+	Origin   string   `json:"origin"`
+	Keys     []string `json:"keys,omitempty"`
+	Patterns []string `json:"patterns,omitempty"`
+}
+
+// publishInvalidation tells the other instances to evict keys and patterns from their
+// local caches
+func (rc *RedisCache) publishInvalidation(ctx context.Context, keys, patterns []string) error {
+	data, err := json.Marshal(invalidation{Origin: rc.instanceID, Keys: keys, Patterns: patterns})
+	if err != nil {
+		return err
+	}
+
+	return rc.client.Publish(ctx, rc.key(invalidationChannel), data).Err()
+}
+
+// StartInvalidationListener evicts the keys other instances invalidate from the local
+// cache until ctx is cancelled. The local cache is purged whenever the subscription is
+// (re)established, since messages sent while disconnected are lost.
+func (rc *RedisCache) StartInvalidationListener(ctx context.Context) {
+	if rc.local == nil {
+		return
+	}
+
+	pubsub := rc.client.Subscribe(ctx, rc.key(invalidationChannel))
+
+	go func() {
+		defer pubsub.Close()
+
+		for {
+			msg, err := pubsub.Receive(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				rc.logger.Error("Cache invalidation listener failed", logger.Err(err))
+				rc.local.purge()
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(invalidationRetryDelay):
+				}
+				continue
+			}
+
+			switch msg := msg.(type) {
+			case *redis.Subscription:
+				if msg.Kind == "subscribe" {
+					rc.local.purge()
+				}
+			case *redis.Message:
+				rc.applyInvalidation(msg.Payload)
+			}
+		}
+	}()
+}
+
+func (rc *RedisCache) applyInvalidation(payload string) {
+	var inv invalidation
+	if err := json.Unmarshal([]byte(payload), &inv); err != nil {
+		rc.logger.Warn("Cache invalidation listener received a malformed message", logger.Err(err))
+		return
+	}
+
+	if inv.Origin == rc.instanceID {
+		return
+	}
+
+	rc.local.delete(inv.Keys...)
+	for _, pattern := range inv.Patterns {
+		rc.local.deletePattern(pattern)
+	}
+}
+
+// maxMissedInvalidations bounds the invalidations kept for replay; past it everything
+// is flushed instead
+const maxMissedInvalidations = 10000
+
+// missedInvalidations collects the keys and patterns whose removal from Redis failed, so
+// they can be removed once Redis is back instead of being served stale
+type missedInvalidations struct {
+	mu       sync.Mutex
+	keys     map[string]struct{}
+	patterns map[string]struct{}
+	overflow bool
+}
+
+func (m *missedInvalidations) add(keys, patterns []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.keys == nil {
+		m.keys = make(map[string]struct{})
+		m.patterns = make(map[string]struct{})
+	}
+	for _, key := range keys {
+		m.keys[key] = struct{}{}
+	}
+	for _, pattern := range patterns {
+		m.patterns[pattern] = struct{}{}
+	}
+
+	if len(m.keys)+len(m.patterns) > maxMissedInvalidations {
+		m.keys, m.patterns, m.overflow = nil, nil, true
+	}
+}
+
+// take empties the collection, returning what it held
+func (m *missedInvalidations) take() (keys, patterns []string, overflow bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key := range m.keys {
+		keys = append(keys, key)
+	}
+	for pattern := range m.patterns {
+		patterns = append(patterns, pattern)
+	}
+	overflow = m.overflow
+
+	m.keys, m.patterns, m.overflow = nil, nil, false
+	return keys, patterns, overflow
+}
+
+// replayInvalidations removes the keys and patterns whose invalidation failed earlier.
+// Whatever fails again is kept for the next attempt.
+func (rc *RedisCache) replayInvalidations(ctx context.Context) error {
+	keys, patterns, overflow := rc.missed.take()
+	if overflow {
+		keys, patterns = nil, []string{"*"}
+	}
+	if len(keys) == 0 && len(patterns) == 0 {
+		return nil
+	}
+
+	var failedKeys, failedPatterns []string
+	if len(keys) > 0 {
+		redisKeys := make([]string, len(keys))
+		for i, key := range keys {
+			redisKeys[i] = rc.key(key)
+		}
+		if _, err := unlink(ctx, rc.client, redisKeys); err != nil {
+			failedKeys = keys
+		}
+	}
+	for _, pattern := range patterns {
+		if _, err := rc.clearRemote(ctx, pattern); err != nil {
+			failedPatterns = append(failedPatterns, pattern)
+		}
+	}
+
+	if len(failedKeys) > 0 || len(failedPatterns) > 0 {
+		rc.missed.add(failedKeys, failedPatterns)
+		return fmt.Errorf("%d keys and %d patterns still pending", len(failedKeys), len(failedPatterns))
+	}
+
+	rc.logger.Info("Replayed missed cache invalidations", logger.Int("keys", len(keys)), logger.Int("patterns", len(patterns)))
+	return rc.publishInvalidation(ctx, keys, patterns)
+}
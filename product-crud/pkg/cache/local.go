@@ -0,0 +1,195 @@
+package cache
+
+import (
+	"container/list"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LocalCache is an in-process LRU tier in front of Redis, bounded by the bytes of the
+// values it holds. It only keeps fresh entries, for at most its TTL, so a missed
+// invalidation is bounded too. A nil *LocalCache is a valid, always-empty cache.
+type LocalCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	ttl      time.Duration
+	size     int64
+	items    map[string]*list.Element
+	lru      *list.List
+	// stats, once the cache is attached to a RedisCache, counts evictions
+	stats *stats
+}
+
+type localItem struct {
+	key       string
+	entry     entry
+	expiresAt time.Time
+}
+
+func NewLocalCache(maxBytes int64, ttl time.Duration) *LocalCache {
+	return &LocalCache{
+		maxBytes: maxBytes,
+		ttl:      ttl,
+		items:    make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+func (c *LocalCache) get(key string) (entry, bool) {
+	if c == nil {
+		return entry{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return entry{}, false
+	}
+
+	item := el.Value.(*localItem)
+	if time.Now().After(item.expiresAt) {
+		c.remove(el)
+		return entry{}, false
+	}
+
+	c.lru.MoveToFront(el)
+	return item.entry, true
+}
+
+// set stores e until the earlier of the local TTL and its soft expiry, evicting the
+// least recently used entries to stay within maxBytes. Markers of absent keys are left
+// to Redis.
+func (c *LocalCache) set(key string, e entry) {
+	if c == nil {
+		return
+	}
+	if e.Deleted {
+		c.delete(key)
+		return
+	}
+
+	expiresAt := time.Now().Add(c.ttl)
+	if e.SoftExpiry != 0 {
+		if soft := time.UnixMilli(e.SoftExpiry); soft.Before(expiresAt) {
+			expiresAt = soft
+		}
+	}
+	if !expiresAt.After(time.Now()) || itemSize(key, e) > c.maxBytes {
+		c.delete(key)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+
+	item := &localItem{key: key, entry: e, expiresAt: expiresAt}
+	c.items[key] = c.lru.PushFront(item)
+	c.size += itemSize(key, e)
+
+	for c.size > c.maxBytes {
+		evicted := c.remove(c.lru.Back())
+		if c.stats != nil {
+			c.stats.evicted(evicted)
+		}
+	}
+}
+
+func (c *LocalCache) countEvictions(s *stats) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats = s
+}
+
+// peek reports whether key is cached, without counting as a use
+func (c *LocalCache) peek(key string) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	return ok && time.Now().Before(el.Value.(*localItem).expiresAt)
+}
+
+func (c *LocalCache) delete(keys ...string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := c.items[key]; ok {
+			c.remove(el)
+		}
+	}
+}
+
+// deletePattern evicts the keys matching a Redis glob pattern
+func (c *LocalCache) deletePattern(pattern string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, el := range c.items {
+		if matchPattern(pattern, key) {
+			c.remove(el)
+		}
+	}
+}
+
+// purge empties the cache, used when invalidations may have been missed
+func (c *LocalCache) purge() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]*list.Element)
+	c.lru.Init()
+	c.size = 0
+}
+
+// remove drops an element and returns its key; callers hold c.mu
+func (c *LocalCache) remove(el *list.Element) string {
+	item := c.lru.Remove(el).(*localItem)
+	delete(c.items, item.key)
+	c.size -= itemSize(item.key, item.entry)
+	return item.key
+}
+
+func itemSize(key string, e entry) int64 {
+	return int64(len(key) + len(e.Value))
+}
+
+// matchPattern reports whether key matches a Redis glob pattern. The prefix patterns
+// the services use are matched directly; others go through path.Match, whose syntax
+// covers the rest of what Redis accepts for keys without slashes.
+func matchPattern(pattern, key string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok && !strings.ContainsAny(prefix, `*?[\`) {
+		return strings.HasPrefix(key, prefix)
+	}
+
+	matched, err := path.Match(pattern, key)
+	return err == nil && matched
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"product-crud/pkg/logger"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// popularityLimit is how many of the most requested items a Popularity keeps counting
+const popularityLimit = 10000
+
+// Popularity counts requests per item in a Redis sorted set shared by every instance,
+// so the most requested items are known across restarts. Counts are batched in memory
+// and added by Flush. A nil *Popularity records nothing.
+type Popularity struct {
+	client  redis.UniversalClient
+	key     string
+	mu      sync.Mutex
+	pending map[string]int64
+	logger  *logger.Logger
+}
+
+func NewPopularity(rc *RedisCache, key string) *Popularity {
+	return &Popularity{
+		client:  rc.client,
+		key:     rc.key(key),
+		pending: make(map[string]int64),
+		logger:  rc.logger,
+	}
+}
+
+// Record counts one request for item
+func (p *Popularity) Record(item string) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending[item]++
+}
+
+// Flush adds the requests recorded since the last flush to the shared counts, keeping
+// only the most requested items
+func (p *Popularity) Flush(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	pending := p.pending
+	p.pending = make(map[string]int64)
+	p.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	pipe := p.client.Pipeline()
+	for item, count := range pending {
+		pipe.ZIncrBy(ctx, p.key, float64(count), item)
+	}
+	pipe.ZRemRangeByRank(ctx, p.key, 0, -popularityLimit-1)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Top returns up to n items, most requested first
+func (p *Popularity) Top(ctx context.Context, n int) ([]string, error) {
+	if p == nil || n <= 0 {
+		return nil, nil
+	}
+
+	return p.client.ZRevRange(ctx, p.key, 0, int64(n)-1).Result()
+}
+
+// Start flushes the recorded requests every interval until ctx is cancelled, then once more
+func (p *Popularity) Start(ctx context.Context, interval time.Duration) {
+	if p == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				if err := p.Flush(context.WithoutCancel(ctx)); err != nil {
+					p.logger.Error("Failed to flush request popularity", logger.Err(err))
+				}
+				return
+			case <-ticker.C:
+				if err := p.Flush(ctx); err != nil {
+					p.logger.Error("Failed to flush request popularity", logger.Err(err))
+				}
+			}
+		}
+	}()
+}
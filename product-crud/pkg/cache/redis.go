@@ -3,89 +3,349 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"product-crud/pkg/logger"
+	"sync/atomic"
 	"time"
 	
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 )
 
+// scanBatchSize is the COUNT hint of each SCAN and the most keys unlinked at once
+const scanBatchSize = 500
+
 // RedisCache provides a Redis-backed caching implementation
 type RedisCache struct {
-	client *redis.Client
+	client redis.UniversalClient
+	// prefix is prepended to every key in Redis, so environments can share it
+	prefix string
 	ttl    time.Duration
+	// staleTTL is how long an entry outlives its soft expiry so Fetch can serve it while refreshing
+	staleTTL time.Duration
+	// negativeTTL is how long Fetch remembers that a key has no value
+	negativeTTL time.Duration
+	group       singleflight.Group
+	// local is the optional in-process tier; instanceID tells this instance's
+	// invalidation messages apart from other replicas'
+	local      *LocalCache
+	instanceID string
+	stats      *stats
+	// breaker fails commands fast while Redis is down; missed holds the invalidations
+	// that could not reach it meanwhile
+	breaker *breakerHook
+	missed  missedInvalidations
+	logger  *logger.Logger
 }
 
-// NewRedisCache creates a new Redis cache client
-func NewRedisCache(addr, password string, ttlSeconds int) (*RedisCache, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: password,
-		DB:       0,
-	})
-	
-	ctx := context.Background()
-	if err := client.Ping(ctx).Err(); err != nil {
+// Config configures a RedisCache
+type Config struct {
+	// URL is a redis:// or rediss:// URL giving the address, credentials and database.
+	// It is an alternative to Addrs, Username, Password and DB, which override it when set.
+	URL string
+	// Mode is standalone, the default, sentinel or cluster. Addrs holds the server,
+	// the sentinels or the cluster nodes to discover the others from.
+	Mode       string
+	Addrs      []string
+	MasterName string
+	Username   string
+	Password   string
+	// SentinelPassword authenticates to the sentinels themselves
+	SentinelPassword string
+	DB               int
+	TLS              TLSConfig
+
+	// Pool settings apply per node; zero keeps the client defaults
+	PoolSize     int
+	MinIdleConns int
+
+	// KeyPrefix is prepended to every key, and to the invalidation channel
+	KeyPrefix string
+
+	TTL         time.Duration
+	StaleTTL    time.Duration
+	NegativeTTL time.Duration
+
+	// Local, if set, is consulted before Redis and kept coherent across instances by
+	// StartInvalidationListener
+	Local *LocalCache
+
+	// OperationTimeout bounds dialing, each read and write, and waiting for a pooled
+	// connection; zero keeps the client defaults
+	OperationTimeout time.Duration
+	Breaker          BreakerConfig
+
+	// Logger receives the cache's logs; nil discards them
+	Logger *logger.Logger
+}
+
+// NewRedisCache creates a new Redis cache client. Only an invalid configuration is an
+// error: while Redis is unreachable the breaker opens, and caching starts once
+// StartHealthCheck finds Redis up.
+func NewRedisCache(cfg Config) (*RedisCache, error) {
+	client, err := newClient(cfg)
+	if err != nil {
 		return nil, err
 	}
-	
-	return &RedisCache{
-		client: client,
-		ttl:    time.Duration(ttlSeconds) * time.Second,
-	}, nil
+
+	rc := &RedisCache{
+		client:      client,
+		prefix:      cfg.KeyPrefix,
+		ttl:         cfg.TTL,
+		staleTTL:    cfg.StaleTTL,
+		negativeTTL: cfg.NegativeTTL,
+		local:       cfg.Local,
+		instanceID:  uuid.New().String(),
+		stats:       &stats{},
+		logger:      cfg.Logger,
+	}
+	if rc.logger == nil {
+		rc.logger = logger.Nop()
+	}
+	rc.local.countEvictions(rc.stats)
+	rc.breaker = newBreakerHook(cfg.Breaker, rc.onBreakerStateChange)
+	client.AddHook(rc.breaker)
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		rc.logger.Warn("Redis unreachable, caching paused until it is back", logger.Err(err))
+	}
+
+	return rc, nil
 }
 
-// Get retrieves a value from cache and unmarshals it into the destination
+// Get retrieves a value from cache and unmarshals it into the destination. Entries past
+// their soft expiry are still returned; use Fetch to have them refreshed.
 func (rc *RedisCache) Get(ctx context.Context, key string, dest interface{}) (bool, error) {
-	val, err := rc.client.Get(ctx, key).Result()
+	if e, ok := rc.local.get(key); ok && json.Unmarshal(e.Value, dest) == nil {
+		rc.stats.localHit(key)
+		return true, nil
+	}
+
+	val, err := rc.client.Get(ctx, rc.key(key)).Bytes()
 	if err == redis.Nil {
+		rc.stats.miss(key)
 		return false, nil // Key not found
 	} else if err != nil {
 		return false, err
 	}
 	
-	if err := json.Unmarshal([]byte(val), dest); err != nil {
+	e, err := decodeEntry(val)
+	if err != nil {
+		return false, err
+	}
+	if e.Deleted {
+		rc.stats.miss(key)
+		return false, nil
+	}
+
+	if err := json.Unmarshal(e.Value, dest); err != nil {
 		return false, err
 	}
+	rc.local.set(key, e)
+	rc.stats.hit(key)
 	
 	return true, nil
 }
 
-// Set serializes and stores a value in the cache with the default TTL
+// Set serializes and stores a value in the cache with the default TTL, jittered so keys
+// written together do not expire together. A Versioned value is only stored if it is
+// not older than the cached one. Other instances are told to drop their local copy of key.
 func (rc *RedisCache) Set(ctx context.Context, key string, value interface{}) error {
-	data, err := json.Marshal(value)
-	if err != nil {
-		return err
-	}
-	
-	return rc.client.Set(ctx, key, data, rc.ttl).Err()
+	return rc.SetWithTTL(ctx, key, value, rc.ttl)
 }
 
-// SetWithTTL serializes and stores a value with a custom TTL
+// SetWithTTL serializes and stores a value with a custom TTL, jittered like Set
 func (rc *RedisCache) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
 	data, err := json.Marshal(value)
 	if err != nil {
 		return err
 	}
 	
-	return rc.client.Set(ctx, key, data, ttl).Err()
+	if _, err := rc.write(ctx, key, data, versionOf(value), ttl, 0); err != nil {
+		// Redis may still hold the previous value
+		rc.missed.add([]string{key}, nil)
+		return err
+	}
+
+	return rc.publishInvalidation(ctx, []string{key}, nil)
 }
 
-// Delete removes a key from the cache
+// GetMany retrieves several keys, from the local cache where possible and otherwise in
+// one round trip, unmarshalling each value found into the matching entry of dests.
+// found[i] reports whether keys[i] was present.
+func (rc *RedisCache) GetMany(ctx context.Context, keys []string, dests []interface{}) ([]bool, error) {
+	found := make([]bool, len(keys))
+
+	var remote []int
+	for i, key := range keys {
+		if e, ok := rc.local.get(key); ok && json.Unmarshal(e.Value, dests[i]) == nil {
+			rc.stats.localHit(key)
+			found[i] = true
+		} else {
+			remote = append(remote, i)
+		}
+	}
+
+	if len(remote) == 0 {
+		return found, nil
+	}
+
+	// MGET fails on a cluster when the keys span hash slots, so each key gets its own GET;
+	// the cluster client still sends one pipeline per node
+	pipe := rc.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(remote))
+	for j, i := range remote {
+		cmds[j] = pipe.Get(ctx, rc.key(keys[i]))
+	}
+	// Missing keys fail their GET with redis.Nil, so errors are checked per command
+	_, _ = pipe.Exec(ctx)
+
+	for j, cmd := range cmds {
+		i := remote[j]
+		val, err := cmd.Bytes()
+		if err == redis.Nil {
+			rc.stats.miss(keys[i])
+			continue // Key not found
+		} else if err != nil {
+			return found, err
+		}
+		e, err := decodeEntry(val)
+		if err != nil {
+			return found, err
+		}
+		if e.Deleted {
+			rc.stats.miss(keys[i])
+			continue
+		}
+		if err := json.Unmarshal(e.Value, dests[i]); err != nil {
+			return found, err
+		}
+		rc.local.set(keys[i], e)
+		rc.stats.hit(keys[i])
+		found[i] = true
+	}
+
+	return found, nil
+}
+
+// SetMany stores several values with the default TTL in one pipelined round trip, with
+// the same version check as Set. It is meant for read-through fills, so unlike Set it
+// does not notify other instances.
+func (rc *RedisCache) SetMany(ctx context.Context, values map[string]interface{}) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	type pending struct {
+		entry  entry
+		stored func() bool
+	}
+
+	writes := make(map[string]pending, len(values))
+	pipe := rc.client.Pipeline()
+	for key, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		version := versionOf(value)
+		e, encoded, expiration, err := rc.encode(data, version, rc.ttl, 0)
+		if err != nil {
+			return err
+		}
+
+		if version == 0 {
+			cmd := pipe.Set(ctx, rc.key(key), encoded, expiration)
+			writes[key] = pending{entry: e, stored: func() bool { return cmd.Err() == nil }}
+		} else {
+			// EVALSHA cannot fall back to EVAL once queued, so the pipeline sends the script
+			cmd := casScript.Eval(ctx, pipe, []string{rc.key(key)}, encoded, version, expiration.Milliseconds())
+			writes[key] = pending{entry: e, stored: func() bool { n, err := cmd.Int(); return err == nil && n == 1 }}
+		}
+	}
+
+	_, err := pipe.Exec(ctx)
+
+	for key, w := range writes {
+		if err == nil && w.stored() {
+			rc.local.set(key, w.entry)
+		} else {
+			rc.local.delete(key)
+		}
+	}
+	return err
+}
+
+// Delete removes a key from the cache, here and in every instance's local cache
 func (rc *RedisCache) Delete(ctx context.Context, key string) error {
-	return rc.client.Del(ctx, key).Err()
+	err := rc.client.Del(ctx, rc.key(key)).Err()
+	if err != nil {
+		rc.missed.add([]string{key}, nil)
+	}
+	rc.local.delete(key)
+
+	return errors.Join(err, rc.publishInvalidation(ctx, []string{key}, nil))
 }
 
-// Clear removes all keys matching the given pattern
+// Clear removes all keys matching the given pattern, here and in every instance's local cache
 func (rc *RedisCache) Clear(ctx context.Context, pattern string) error {
-	keys, err := rc.client.Keys(ctx, pattern).Result()
+	_, err := rc.Flush(ctx, pattern)
+	return err
+}
+
+// Flush is Clear, also reporting how many keys were removed from Redis. Keys are found
+// with SCAN and removed with UNLINK, so a large flush does not block Redis.
+func (rc *RedisCache) Flush(ctx context.Context, pattern string) (int64, error) {
+	// Evict locally only once Redis is cleared, so no instance refills from the old values
+	deleted, err := rc.clearRemote(ctx, pattern)
 	if err != nil {
-		return err
+		rc.missed.add(nil, []string{pattern})
 	}
-	
-	if len(keys) > 0 {
-		return rc.client.Del(ctx, keys...).Err()
-	}
-	
-	return nil
+	rc.local.deletePattern(pattern)
+
+	return deleted, errors.Join(err, rc.publishInvalidation(ctx, nil, []string{pattern}))
+}
+
+// clearRemote removes the keys matching pattern from Redis, scanning every master of a
+// cluster in parallel
+func (rc *RedisCache) clearRemote(ctx context.Context, pattern string) (int64, error) {
+	var deleted atomic.Int64
+
+	err := rc.forEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+		batch := make([]string, 0, scanBatchSize)
+		flush := func() error {
+			n, err := unlink(ctx, client, batch)
+			deleted.Add(n)
+			batch = batch[:0]
+			return err
+		}
+
+		iter := client.Scan(ctx, 0, rc.key(pattern), scanBatchSize).Iterator()
+		for iter.Next(ctx) {
+			batch = append(batch, iter.Val())
+			if len(batch) == scanBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+
+		if len(batch) > 0 {
+			return flush()
+		}
+		return nil
+	})
+
+	return deleted.Load(), err
+}
+
+// key returns the Redis key of a cache key
+func (rc *RedisCache) key(key string) string {
+	return rc.prefix + key
 }
 
 // Close closes the underlying Redis connection
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// newTestCache returns an instance without a local tier on top of server, so reads see
+// exactly what Redis holds
+func newTestCache(t *testing.T, server *miniredis.Miniredis) *RedisCache {
+	t.Helper()
+
+	rc, err := NewRedisCache(Config{
+		Addrs:    []string{server.Addr()},
+		TTL:      time.Minute,
+		StaleTTL: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewRedisCache: %v", err)
+	}
+	t.Cleanup(func() { _ = rc.Close() })
+	return rc
+}
+
+func TestSetGetDelete(t *testing.T) {
+	ctx := context.Background()
+	rc := newTestCache(t, miniredis.RunT(t))
+
+	if err := rc.Set(ctx, "greeting", "hello"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	var got string
+	found, err := rc.Get(ctx, "greeting", &got)
+	if err != nil || !found || got != "hello" {
+		t.Fatalf("Get = %q, %t, %v; want hello, true, nil", got, found, err)
+	}
+
+	if err := rc.Delete(ctx, "greeting"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if found, err := rc.Get(ctx, "greeting", &got); err != nil || found {
+		t.Fatalf("Get after Delete = %t, %v; want false, nil", found, err)
+	}
+}
+
+func TestKeyPrefix(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+
+	rc, err := NewRedisCache(Config{Addrs: []string{server.Addr()}, KeyPrefix: "staging:", TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("NewRedisCache: %v", err)
+	}
+	defer rc.Close()
+
+	if err := rc.Set(ctx, "product:1", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if !server.Exists("staging:product:1") {
+		t.Fatalf("keys = %v, want staging:product:1", server.Keys())
+	}
+}
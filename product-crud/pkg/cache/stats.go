@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// counters tallies the lookups of one key namespace
+type counters struct {
+	hits      atomic.Int64
+	localHits atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+}
+
+// stats keeps counters per namespace, the part of a key before its first colon
+type stats struct {
+	namespaces sync.Map // namespace -> *counters
+}
+
+func (s *stats) of(key string) *counters {
+	ns := Namespace(key)
+	if c, ok := s.namespaces.Load(ns); ok {
+		return c.(*counters)
+	}
+	c, _ := s.namespaces.LoadOrStore(ns, &counters{})
+	return c.(*counters)
+}
+
+func (s *stats) hit(key string)     { s.of(key).hits.Add(1) }
+func (s *stats) miss(key string)    { s.of(key).misses.Add(1) }
+func (s *stats) evicted(key string) { s.of(key).evictions.Add(1) }
+
+func (s *stats) localHit(key string) {
+	c := s.of(key)
+	c.hits.Add(1)
+	c.localHits.Add(1)
+}
+
+// NamespaceStats counts this instance's lookups of one namespace since it started.
+// Hits include LocalHits, served without asking Redis; Evictions are local cache entries
+// dropped to stay within its size bound.
+type NamespaceStats struct {
+	Hits      int64 `json:"hits"`
+	LocalHits int64 `json:"local_hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+	// HitRatio is Hits over all lookups, 0 when there were none
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+// ServerStats are Redis' own counters, shared by every instance and namespace and summed
+// over the masters of a cluster
+type ServerStats struct {
+	KeyspaceHits   int64 `json:"keyspace_hits"`
+	KeyspaceMisses int64 `json:"keyspace_misses"`
+	EvictedKeys    int64 `json:"evicted_keys"`
+	ExpiredKeys    int64 `json:"expired_keys"`
+	UsedMemory     int64 `json:"used_memory"`
+	Keys           int64 `json:"keys"`
+}
+
+// Stats is a snapshot of the cache statistics. Server is missing while Redis is unreachable.
+type Stats struct {
+	Namespaces map[string]NamespaceStats `json:"namespaces"`
+	Breaker    BreakerStats              `json:"breaker"`
+	Server     *ServerStats              `json:"server,omitempty"`
+}
+
+// Stats returns the per-namespace counters and breaker state of this instance, along with
+// the Redis server's counters when Redis answers
+func (rc *RedisCache) Stats(ctx context.Context) (*Stats, error) {
+	result := &Stats{
+		Namespaces: make(map[string]NamespaceStats),
+		Breaker:    rc.Breaker(),
+	}
+
+	rc.stats.namespaces.Range(func(ns, value interface{}) bool {
+		c := value.(*counters)
+		s := NamespaceStats{
+			Hits:      c.hits.Load(),
+			LocalHits: c.localHits.Load(),
+			Misses:    c.misses.Load(),
+			Evictions: c.evictions.Load(),
+		}
+		if total := s.Hits + s.Misses; total > 0 {
+			s.HitRatio = float64(s.Hits) / float64(total)
+		}
+		result.Namespaces[ns.(string)] = s
+		return true
+	})
+
+	// On a cluster every master holds part of the keys, so their counters are summed
+	var mu sync.Mutex
+	server := &ServerStats{}
+	err := rc.forEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+		info, err := client.Info(ctx, "stats", "memory").Result()
+		if err != nil {
+			return err
+		}
+		keys, err := client.DBSize(ctx).Result()
+		if err != nil {
+			return err
+		}
+
+		fields := parseInfo(info)
+		mu.Lock()
+		defer mu.Unlock()
+		server.KeyspaceHits += fields["keyspace_hits"]
+		server.KeyspaceMisses += fields["keyspace_misses"]
+		server.EvictedKeys += fields["evicted_keys"]
+		server.ExpiredKeys += fields["expired_keys"]
+		server.UsedMemory += fields["used_memory"]
+		server.Keys += keys
+		return nil
+	})
+	if errors.Is(err, ErrUnavailable) {
+		return result, nil
+	} else if err != nil {
+		return nil, err
+	}
+	result.Server = server
+
+	return result, nil
+}
+
+// Namespace returns the namespace key is counted under
+func Namespace(key string) string {
+	ns, _, _ := strings.Cut(key, ":")
+	return ns
+}
+
+// parseInfo extracts the integer fields of an INFO reply
+func parseInfo(info string) map[string]int64 {
+	fields := make(map[string]int64)
+
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok || strings.HasPrefix(name, "#") {
+			continue
+		}
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			fields[name] = n
+		}
+	}
+
+	return fields
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Versioned is implemented by values whose cached copies must never be replaced by an
+// older copy, such as a database row read before a concurrent update committed. Versions
+// must fit in 53 bits, since Redis compares them as Lua numbers.
+type Versioned interface {
+	CacheVersion() int64
+}
+
+const (
+	// tombstoneVersion outranks every real version, so nothing replaces a tombstone
+	tombstoneVersion = 1<<53 - 1
+	// missVersion ranks below every real version, so any value replaces a cached miss
+	missVersion = 1
+)
+
+// casScript stores an entry unless the stored one has a higher version.
+// KEYS[1] is the key; ARGV holds the encoded entry, its version and the expiration in
+// milliseconds, 0 meaning none. It returns 1 if the entry was stored.
+var casScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if current then
+  local ok, stored = pcall(cjson.decode, current)
+  if ok and type(stored) == 'table' and tonumber(stored.ver) and tonumber(stored.ver) > tonumber(ARGV[2]) then
+    return 0
+  end
+end
+if tonumber(ARGV[3]) > 0 then
+  redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[3])
+else
+  redis.call('SET', KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+// Retire deletes key for good: it is replaced by a tombstone that reads as a miss and
+// that no versioned write can overwrite before it expires, so a read that started before
+// the deletion cannot bring the value back.
+func (rc *RedisCache) Retire(ctx context.Context, key string) error {
+	e := entry{Value: json.RawMessage("null"), Version: tombstoneVersion, Deleted: true}
+
+	encoded, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	// Outlive any fill that could still be in flight
+	expiration := rc.ttl + rc.staleTTL
+	if _, err := rc.store(ctx, key, encoded, e.Version, expiration); err != nil {
+		rc.missed.add([]string{key}, nil)
+		rc.local.delete(key)
+		return err
+	}
+
+	rc.local.delete(key)
+	return rc.publishInvalidation(ctx, []string{key}, nil)
+}
+
+// store writes an encoded entry, through casScript if it is versioned, and reports
+// whether it was stored
+func (rc *RedisCache) store(ctx context.Context, key string, encoded []byte, version int64, expiration time.Duration) (bool, error) {
+	if version == 0 {
+		return true, rc.client.Set(ctx, rc.key(key), encoded, expiration).Err()
+	}
+
+	stored, err := casScript.Run(ctx, rc.client, []string{rc.key(key)}, encoded, version, expiration.Milliseconds()).Int()
+	return stored == 1, err
+}
+
+// versionOf returns the version of value, or 0 if it is not versioned
+func versionOf(value interface{}) int64 {
+	if v, ok := value.(Versioned); ok {
+		return v.CacheVersion()
+	}
+	return 0
+}
@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// snapshot stands in for a product row read at some version
+type snapshot struct {
+	Version int64 `json:"version"`
+}
+
+func (s *snapshot) CacheVersion() int64 {
+	return s.Version
+}
+
+// TestVersionedWrites replays the interleavings of cache fills, updates and deletes that
+// used to leave stale products in Redis. Two instances, writer and reader, share one
+// Redis; each scenario returns the version that must end up cached, 0 meaning none.
+func TestVersionedWrites(t *testing.T) {
+	scenarios := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, writer, reader *RedisCache, key string) int64
+	}{
+		{"read-through fill finishing after an update", func(t *testing.T, ctx context.Context, writer, reader *RedisCache, key string) int64 {
+			fillAround(t, ctx, reader, key, 1, func() {
+				mustSucceed(t, writer.Set(ctx, key, &snapshot{Version: 2}))
+			})
+			return 2
+		}},
+		{"bulk fill finishing after an update", func(t *testing.T, ctx context.Context, writer, reader *RedisCache, key string) int64 {
+			mustSucceed(t, writer.Set(ctx, key, &snapshot{Version: 2}))
+			mustSucceed(t, reader.SetMany(ctx, map[string]interface{}{key: &snapshot{Version: 1}}))
+			return 2
+		}},
+		{"updates cached out of order", func(t *testing.T, ctx context.Context, writer, reader *RedisCache, key string) int64 {
+			mustSucceed(t, writer.Set(ctx, key, &snapshot{Version: 2}))
+			mustSucceed(t, reader.Set(ctx, key, &snapshot{Version: 1}))
+			return 2
+		}},
+		{"newer fill replacing an older entry", func(t *testing.T, ctx context.Context, writer, reader *RedisCache, key string) int64 {
+			mustSucceed(t, writer.Set(ctx, key, &snapshot{Version: 1}))
+			mustSucceed(t, reader.SetMany(ctx, map[string]interface{}{key: &snapshot{Version: 2}}))
+			return 2
+		}},
+		{"fill finishing after a delete", func(t *testing.T, ctx context.Context, writer, reader *RedisCache, key string) int64 {
+			mustSucceed(t, writer.Set(ctx, key, &snapshot{Version: 1}))
+			mustSucceed(t, writer.Retire(ctx, key))
+			mustSucceed(t, reader.SetMany(ctx, map[string]interface{}{key: &snapshot{Version: 1}}))
+			return 0
+		}},
+		{"read-through fill finishing after a change to a child row", func(t *testing.T, ctx context.Context, writer, reader *RedisCache, key string) int64 {
+			// A variant or image change bumps the parent's version and caches the fresh copy,
+			// where a plain delete would let the fill restore the old one
+			mustSucceed(t, writer.Set(ctx, key, &snapshot{Version: 1}))
+			mustSucceed(t, writer.Delete(ctx, key))
+			fillAround(t, ctx, reader, key, 1, func() {
+				mustSucceed(t, writer.Set(ctx, key, &snapshot{Version: 2}))
+			})
+			return 2
+		}},
+		{"read-through fill finishing after a child row change that could not be reloaded", func(t *testing.T, ctx context.Context, writer, reader *RedisCache, key string) int64 {
+			fillAround(t, ctx, reader, key, 1, func() {
+				mustSucceed(t, writer.Retire(ctx, key))
+			})
+			return 0
+		}},
+		{"concurrent fills and updates", func(t *testing.T, ctx context.Context, writer, reader *RedisCache, key string) int64 {
+			const writes = 200
+
+			var wg sync.WaitGroup
+			errs := make(chan error, writes)
+			for i := 1; i <= writes; i++ {
+				wg.Add(1)
+				go func(version int64) {
+					defer wg.Done()
+					value := &snapshot{Version: version}
+					if version%2 == 0 {
+						errs <- writer.Set(ctx, key, value)
+					} else {
+						errs <- reader.SetMany(ctx, map[string]interface{}{key: value})
+					}
+				}(int64(i))
+			}
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				mustSucceed(t, err)
+			}
+			return writes
+		}},
+	}
+
+	for _, sc := range scenarios {
+		t.Run(sc.name, func(t *testing.T) {
+			ctx := context.Background()
+			server := miniredis.RunT(t)
+			writer, reader, observer := newTestCache(t, server), newTestCache(t, server), newTestCache(t, server)
+
+			const key = "product:1"
+			want := sc.run(t, ctx, writer, reader, key)
+
+			var got snapshot
+			found, err := observer.Get(ctx, key, &got)
+			switch {
+			case err != nil:
+				t.Fatalf("Get: %v", err)
+			case want == 0 && found:
+				t.Fatalf("want no entry, got version %d", got.Version)
+			case want != 0 && (!found || got.Version != want):
+				t.Fatalf("want version %d, got %+v (found %t)", want, got, found)
+			}
+		})
+	}
+}
+
+// fillAround runs a read-through fetch of key on reader that loads version, and runs during
+// after the load but before the fill is written
+func fillAround(t *testing.T, ctx context.Context, reader *RedisCache, key string, version int64, during func()) {
+	t.Helper()
+	loaded := make(chan struct{})
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	var fetchErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var dest snapshot
+		_, fetchErr = reader.Fetch(ctx, key, &dest, func(ctx context.Context) (interface{}, error) {
+			close(loaded)
+			<-release
+			return &snapshot{Version: version}, nil
+		})
+	}()
+
+	<-loaded
+	during()
+	close(release)
+	wg.Wait()
+
+	mustSucceed(t, fetchErr)
+}
+
+func mustSucceed(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
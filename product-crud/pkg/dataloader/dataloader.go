@@ -0,0 +1,145 @@
+package dataloader
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchFunc fetches the values of keys in one call. Keys missing from the returned map
+// resolve to the zero value, which lets callers treat absent records as not found.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+const (
+	defaultWait     = 2 * time.Millisecond
+	defaultMaxBatch = 100
+)
+
+// Loader coalesces the Loads issued within a short window into one BatchFunc call and
+// memoizes the results. A Loader is meant to live for a single request.
+type Loader[K comparable, V any] struct {
+	fetch    BatchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	calls   map[K]*call[V]
+	pending *batch[K]
+}
+
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type batch[K comparable] struct {
+	ctx  context.Context
+	keys []K
+	once sync.Once
+}
+
+// New creates a loader that waits up to wait for more keys before fetching, and never
+// fetches more than maxBatch keys at once. Non-positive values select the defaults.
+func New[K comparable, V any](fetch BatchFunc[K, V], wait time.Duration, maxBatch int) *Loader[K, V] {
+	if wait <= 0 {
+		wait = defaultWait
+	}
+	if maxBatch <= 0 {
+		maxBatch = defaultMaxBatch
+	}
+
+	return &Loader[K, V]{
+		fetch:    fetch,
+		wait:     wait,
+		maxBatch: maxBatch,
+		calls:    make(map[K]*call[V]),
+	}
+}
+
+// Load returns the value of key, joining the batch currently being collected
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	c, ok := l.calls[key]
+	if !ok {
+		c = &call[V]{done: make(chan struct{})}
+		l.calls[key] = c
+		l.enqueue(ctx, key)
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// LoadMany returns the values of keys in order, stopping at the first error
+func (l *Loader[K, V]) LoadMany(ctx context.Context, keys []K) ([]V, error) {
+	results := make([]V, len(keys))
+	errs := make([]error, len(keys))
+
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func(i int, key K) {
+			defer wg.Done()
+			results[i], errs[i] = l.Load(ctx, key)
+		}(i, key)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// enqueue adds key to the pending batch, starting a new one if needed. Callers hold l.mu.
+func (l *Loader[K, V]) enqueue(ctx context.Context, key K) {
+	if l.pending == nil {
+		b := &batch[K]{ctx: context.WithoutCancel(ctx)}
+		l.pending = b
+		time.AfterFunc(l.wait, func() { l.dispatch(b) })
+	}
+
+	l.pending.keys = append(l.pending.keys, key)
+	if len(l.pending.keys) >= l.maxBatch {
+		b := l.pending
+		l.pending = nil
+		go l.dispatch(b)
+	}
+}
+
+// dispatch fetches a batch once, whether triggered by the timer or by the batch filling up
+func (l *Loader[K, V]) dispatch(b *batch[K]) {
+	b.once.Do(func() {
+		l.mu.Lock()
+		if l.pending == b {
+			l.pending = nil
+		}
+		l.mu.Unlock()
+
+		values, err := l.fetch(b.ctx, b.keys)
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		for _, key := range b.keys {
+			c := l.calls[key]
+			if err != nil {
+				c.err = err
+				// Forget failures so a later Load can retry
+				delete(l.calls, key)
+			} else {
+				c.value = values[key]
+			}
+			close(c.done)
+		}
+	})
+}
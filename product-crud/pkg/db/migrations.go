@@ -0,0 +1,96 @@
+package db
+
+import (
+	"product-crud/internal/model"
+	"product-crud/pkg/logger"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migration is a versioned schema change that AutoMigrate cannot express on its own,
+// such as column type conversions or data backfills
+type migration struct {
+	Version string
+	Up      func(tx *gorm.DB) error
+}
+
+type schemaMigration struct {
+	Version   string    `gorm:"primaryKey"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// migrations run in order before AutoMigrate, so each one must tolerate a fresh
+// database in which the tables it touches do not exist yet
+var migrations = []migration{
+	{
+		Version: "20261019_01_money_prices",
+		Up: func(tx *gorm.DB) error {
+			return execAll(tx,
+				`ALTER TABLE IF EXISTS products ALTER COLUMN price TYPE NUMERIC(19,4) USING ROUND(price::numeric, 4)`,
+				`ALTER TABLE IF EXISTS products ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD'`,
+			)
+		},
+	},
+	{
+		// Seed the price history with each existing product's current price
+		Version: "20261019_02_price_history",
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasTable("products") {
+				return nil
+			}
+			if err := tx.AutoMigrate(&model.PriceHistory{}); err != nil {
+				return err
+			}
+			return execAll(tx,
+				`INSERT INTO price_history (product_id, amount, currency, effective_from, applied_at, created_at)
+				SELECT p.id, p.price, p.currency, p.created_at, p.created_at, NOW()
+				FROM products p
+				WHERE NOT EXISTS (SELECT 1 FROM price_history h WHERE h.product_id = p.id)`,
+			)
+		},
+	},
+}
+
+func runMigrations(db *gorm.DB, log *logger.Logger) error {
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		var count int64
+		if err := db.Model(&schemaMigration{}).Where("version = ?", m.Version).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Version: m.Version, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return err
+		}
+
+		log.Info("Applied migration", logger.String("version", m.Version))
+	}
+
+	return nil
+}
+
+func execAll(tx *gorm.DB, statements ...string) error {
+	for _, stmt := range statements {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
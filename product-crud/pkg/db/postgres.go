@@ -1,49 +1,305 @@
 package db
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"net/url"
 	"os"
 	"product-crud/internal/model"
+	"product-crud/pkg/logger"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func Connect() (*gorm.DB, error) {
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
+const (
+	// connectBackoff and maxConnectBackoff bound the wait between attempts to reach the primary
+	connectBackoff    = 500 * time.Millisecond
+	maxConnectBackoff = 10 * time.Second
+	// pingTimeout bounds each attempt
+	pingTimeout = 5 * time.Second
+)
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// Config describes the primary database, its read replicas and the connection pools
+type Config struct {
+	// DSN is a keyword/value string or a postgres:// URL. When empty, one is built from
+	// Host, Port, User, Password and Name.
+	DSN      string
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+
+	// SSLMode (disable, allow, prefer, require, verify-ca or verify-full) and the
+	// certificate files, when set, override those of the primary and replica DSNs
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	ReplicaDSNs []string
+
+	// Pool settings apply to the primary and to each replica; zero keeps database/sql's default
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
 
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname)
+	// ConnectTimeout is how long Connect keeps retrying the primary, backing off
+	// exponentially between attempts
+	ConnectTimeout time.Duration
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// Logger receives the cluster's logs and GORM's, those of statements slower than
+	// SlowQueryThreshold as warnings; nil discards them
+	Logger             *logger.Logger
+	SlowQueryThreshold time.Duration
+}
+
+// Connect opens the primary database, waiting for it to come up for up to
+// cfg.ConnectTimeout, and the read replicas. Its errors never contain the passwords of
+// the DSNs, which drivers and net/url may quote.
+func Connect(cfg Config) (*Cluster, error) {
+	cluster, err := connect(cfg)
 	if err != nil {
+		return nil, cfg.redact(err)
+	}
+	return cluster, nil
+}
+
+func connect(cfg Config) (*Cluster, error) {
+	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
 
-	sqlDB, err := db.DB()
+	dsn := cfg.DSN
+	if dsn == "" {
+		dsn = keywordDSN(map[string]string{
+			"host":     cfg.Host,
+			"port":     cfg.Port,
+			"user":     cfg.User,
+			"password": cfg.Password,
+			"dbname":   cfg.Name,
+		})
+	}
+
+	db, err := cfg.open(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.waitForPrimary(db); err != nil {
+		return nil, err
+	}
+
+	replicas, err := openReplicas(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := sqlDB.Ping(); err != nil {
+	cfg.logger().Info("Successfully connected to the database", logger.Int("replicas", len(replicas)))
+	return &Cluster{primary: db, replicas: replicas, logger: cfg.logger()}, nil
+}
+
+func (cfg Config) validate() error {
+	if cfg.SSLMode != "" && !slices.Contains(sslModes, cfg.SSLMode) {
+		return fmt.Errorf("invalid SSL mode %q, want one of %s", cfg.SSLMode, strings.Join(sslModes, ", "))
+	}
+
+	for _, file := range []string{cfg.SSLRootCert, cfg.SSLCert, cfg.SSLKey} {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Errorf("TLS file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// open configures a pool for dsn without connecting yet
+func (cfg Config) logger() *logger.Logger {
+	if cfg.Logger == nil {
+		return logger.Nop()
+	}
+	return cfg.Logger
+}
+
+func (cfg Config) open(dsn string) (*gorm.DB, error) {
+	dsn, err := withParams(dsn, map[string]string{
+		"sslmode":     cfg.SSLMode,
+		"sslrootcert": cfg.SSLRootCert,
+		"sslcert":     cfg.SSLCert,
+		"sslkey":      cfg.SSLKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gormLogger := logger.NewGormLogger(cfg.logger(), cfg.SlowQueryThreshold)
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{DisableAutomaticPing: true, Logger: gormLogger})
+	if err != nil {
 		return nil, err
 	}
 
-	log.Println("Successfully connected to the database")
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
 	return db, nil
 }
 
-func InitSchema(db *gorm.DB) error {
-	err := db.AutoMigrate(&model.Product{})
+// waitForPrimary pings the database until it answers or cfg.ConnectTimeout has passed
+func (cfg Config) waitForPrimary(db *gorm.DB) error {
+	sqlDB, err := db.DB()
 	if err != nil {
 		return err
 	}
 
-	log.Println("Database schema initialized")
+	deadline := time.Now().Add(cfg.ConnectTimeout)
+	backoff := connectBackoff
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+		err := sqlDB.PingContext(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("database unreachable after %d attempts: %w", attempt, err)
+		}
+		wait := min(jitter(backoff), remaining)
+
+		cfg.logger().Warn("Database not reachable, retrying",
+			logger.Int("attempt", attempt), logger.Duration("wait", wait.Round(time.Millisecond)), logger.Err(cfg.redact(err)))
+		time.Sleep(wait)
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+}
+
+// redact removes the passwords of cfg's DSNs, whether plain or URL-escaped, from err's
+// message, on top of what the logger redacts anyway
+func (cfg Config) redact(err error) error {
+	var patterns []string
+	for _, password := range cfg.passwords() {
+		for _, form := range []string{password, url.QueryEscape(password), url.PathEscape(password)} {
+			patterns = append(patterns, regexp.QuoteMeta(form))
+		}
+	}
+
+	redactor, rerr := logger.NewRedactor(logger.RedactionConfig{Patterns: patterns})
+	if rerr != nil {
+		// Quoted patterns always compile; fall back to the message alone
+		return errors.New(logger.Redacted)
+	}
+	return &redactedError{msg: redactor.String(err.Error()), err: err}
+}
+
+// passwords returns the passwords found in cfg and in its DSNs
+func (cfg Config) passwords() []string {
+	var passwords []string
+	if cfg.Password != "" {
+		passwords = append(passwords, cfg.Password)
+	}
+	for _, dsn := range append([]string{cfg.DSN}, cfg.ReplicaDSNs...) {
+		if dsn == "" {
+			continue
+		}
+		if parsed, err := pgconn.ParseConfig(dsn); err == nil && parsed.Password != "" {
+			passwords = append(passwords, parsed.Password)
+		}
+	}
+	return passwords
+}
+
+// redactedError replaces the message of err, which stays reachable with errors.Is and As
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
+
+func InitSchema(db *gorm.DB, log *logger.Logger) error {
+	if err := runMigrations(db, log); err != nil {
+		return err
+	}
+
+	err := db.AutoMigrate(&model.Category{}, &model.Product{}, &model.ProductPrice{}, &model.ProductTag{},
+		&model.ProductOption{}, &model.ProductVariant{}, &model.ProductImage{}, &model.ProductImageVariant{},
+		&model.StockLevel{}, &model.StockMovement{}, &model.Reservation{}, &model.PriceHistory{})
+	if err != nil {
+		return err
+	}
+
+	log.Info("Database schema initialized")
 	return nil
 }
+
+// withParams sets the non-empty params on a keyword/value or URL DSN, replacing any
+// value it already has
+func withParams(dsn string, params map[string]string) (string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", fmt.Errorf("invalid database URL: %w", err)
+		}
+
+		query := u.Query()
+		for key, value := range params {
+			if value != "" {
+				query.Set(key, value)
+			}
+		}
+		u.RawQuery = query.Encode()
+		return u.String(), nil
+	}
+
+	// A repeated keyword overrides the earlier one
+	if extra := keywordDSN(params); extra != "" {
+		dsn = strings.TrimSpace(dsn + " " + extra)
+	}
+	return dsn, nil
+}
+
+// keywordDSN formats the non-empty params as a keyword/value DSN, quoting every value
+func keywordDSN(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for key, value := range params {
+		if value != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(params[key])
+		parts = append(parts, key+"='"+value+"'")
+	}
+	return strings.Join(parts, " ")
+}
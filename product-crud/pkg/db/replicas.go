@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"product-crud/pkg/logger"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// replicaPingTimeout bounds each health check of a replica
+const replicaPingTimeout = 2 * time.Second
+
+// Cluster is the primary database and its read replicas. Writes, and reads that must
+// see them, go to the primary; other reads are spread over the healthy replicas.
+type Cluster struct {
+	primary  *gorm.DB
+	replicas []*replica
+	next     atomic.Uint64
+	logger   *logger.Logger
+}
+
+type replica struct {
+	name    string
+	db      *gorm.DB
+	healthy atomic.Bool
+}
+
+type primaryKey struct{}
+
+// WithPrimary returns a context whose reads go to the primary, for reads that must see
+// a write made just before
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func requiresPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+// Primary returns the database that takes writes
+func (c *Cluster) Primary() *gorm.DB {
+	return c.primary
+}
+
+// Reader returns the database to read from: the next healthy replica in turn, or the
+// primary if ctx requires it or no replica is healthy
+func (c *Cluster) Reader(ctx context.Context) *gorm.DB {
+	// Statements carry ctx, so they are logged with the request's logger
+	return c.pick(ctx).WithContext(ctx)
+}
+
+func (c *Cluster) pick(ctx context.Context) *gorm.DB {
+	if len(c.replicas) == 0 || requiresPrimary(ctx) {
+		return c.primary
+	}
+
+	start := c.next.Add(1)
+	for i := range c.replicas {
+		r := c.replicas[(start+uint64(i))%uint64(len(c.replicas))]
+		if r.healthy.Load() {
+			return r.db
+		}
+	}
+
+	return c.primary
+}
+
+// StartHealthChecks pings every replica each interval until ctx is cancelled, taking
+// those that fail out of rotation until they answer again
+func (c *Cluster) StartHealthChecks(ctx context.Context, interval time.Duration) {
+	if len(c.replicas) == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for _, r := range c.replicas {
+					r.check(ctx, c.logger)
+				}
+			}
+		}
+	}()
+}
+
+// check pings the replica and records whether it is healthy, logging changes to log
+func (r *replica) check(ctx context.Context, log *logger.Logger) {
+	err := r.ping(ctx)
+
+	healthy := err == nil
+	if r.healthy.Swap(healthy) == healthy {
+		return
+	}
+
+	if healthy {
+		log.Info("Database back in rotation", logger.String("database", r.name))
+	} else {
+		log.Warn("Database taken out of rotation", logger.String("database", r.name), logger.Err(err))
+	}
+}
+
+func (r *replica) ping(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
+
+// openReplicas opens a pool per replica. A replica that cannot be reached yet is kept
+// out of rotation until a health check succeeds.
+func openReplicas(cfg Config) ([]*replica, error) {
+	replicas := make([]*replica, 0, len(cfg.ReplicaDSNs))
+	for i, dsn := range cfg.ReplicaDSNs {
+		db, err := cfg.open(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("replica %d: %w", i+1, err)
+		}
+
+		r := &replica{name: fmt.Sprintf("replica %d", i+1), db: db}
+		r.check(context.Background(), cfg.logger())
+		replicas = append(replicas, r)
+	}
+
+	return replicas, nil
+}
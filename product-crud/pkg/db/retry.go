@@ -0,0 +1,86 @@
+package db
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxTransactionAttempts is how many times Transaction runs a transaction that keeps
+	// failing transiently
+	maxTransactionAttempts = 3
+	// transactionBackoff is the wait before the first retry; it doubles for each one after
+	transactionBackoff = 50 * time.Millisecond
+)
+
+// SQLSTATEs after which the server has rolled the transaction back
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+	// adminShutdown is sent when the server terminates the connection, e.g. on failover
+	adminShutdown = "57P01"
+)
+
+// Transaction runs fn in a transaction, rerunning it when it fails because of a
+// serialization failure, a deadlock or a connection lost before the transaction could
+// have committed. fn must therefore be safe to run again from the start.
+func Transaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	backoff := transactionBackoff
+	for attempt := 1; ; attempt++ {
+		committing := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := fn(tx); err != nil {
+				return err
+			}
+			committing = true
+			return nil
+		})
+		if err == nil || attempt == maxTransactionAttempts || !retryable(err, committing) {
+			return err
+		}
+
+		// Through GORM's logger, so the retry is logged like the statements, with the request ID
+		db.Logger.Warn(db.Statement.Context, "Retrying transaction after transient error (attempt %d): %v", attempt, err)
+		time.Sleep(jitter(backoff))
+		backoff *= 2
+	}
+}
+
+// retryable reports whether a transaction that failed with err may be rerun. A
+// connection lost while committing leaves the outcome unknown, so it is not retried.
+func retryable(err error, committing bool) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case serializationFailure, deadlockDetected:
+			return true
+		case adminShutdown:
+			return !committing
+		}
+		return false
+	}
+
+	return !committing && isConnectionError(err)
+}
+
+// isConnectionError reports whether err means the connection was refused or lost, which
+// rolls back any open transaction
+func isConnectionError(err error) bool {
+	var connectErr *pgconn.ConnectError
+	if pgconn.SafeToRetry(err) || errors.As(err, &connectErr) {
+		return true
+	}
+
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+}
+
+// jitter spreads d by up to ±20%, so instances retrying together do not stay in step
+func jitter(d time.Duration) time.Duration {
+	return d + time.Duration((rand.Float64()*0.4-0.2)*float64(d))
+}
@@ -0,0 +1,160 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// DefaultPresets is used when no presets are configured
+const DefaultPresets = "thumb:150x150,medium:600x600,large:1200x1200"
+
+// MaxPixels caps the decoded size of a source image so small but highly compressed
+// uploads cannot exhaust memory
+const MaxPixels = 50_000_000
+
+// jpegQuality balances size and fidelity for photographic variants
+const jpegQuality = 85
+
+// Preset is a named bounding box that a variant is scaled down to fit in
+type Preset struct {
+	Name   string
+	Width  int
+	Height int
+}
+
+// Rendition is an encoded variant ready to be stored
+type Rendition struct {
+	Preset      Preset
+	Data        []byte
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+}
+
+// ParsePresets parses a comma separated list of name:WIDTHxHEIGHT entries, ordered by size
+func ParsePresets(spec string) ([]Preset, error) {
+	var presets []Preset
+	seen := make(map[string]bool)
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, size, ok := strings.Cut(entry, ":")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid image preset %q, expected name:WIDTHxHEIGHT", entry)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate image preset %q", name)
+		}
+
+		w, h, ok := strings.Cut(size, "x")
+		width, wErr := strconv.Atoi(w)
+		height, hErr := strconv.Atoi(h)
+		if !ok || wErr != nil || hErr != nil || width <= 0 || height <= 0 {
+			return nil, fmt.Errorf("invalid size for image preset %q", name)
+		}
+
+		seen[name] = true
+		presets = append(presets, Preset{Name: name, Width: width, Height: height})
+	}
+
+	if len(presets) == 0 {
+		return nil, fmt.Errorf("no image presets configured")
+	}
+
+	sort.SliceStable(presets, func(i, j int) bool {
+		return presets[i].Width*presets[i].Height < presets[j].Width*presets[j].Height
+	})
+
+	return presets, nil
+}
+
+// Render decodes the source image once and produces one rendition per preset.
+// Images are only ever scaled down; a source smaller than a preset is re-encoded as is.
+func Render(data []byte, presets []Preset) ([]Rendition, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, fmt.Errorf("image of %dx%d pixels is too large to resize", cfg.Width, cfg.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+
+	renditions := make([]Rendition, 0, len(presets))
+	for _, preset := range presets {
+		img := Fit(src, preset.Width, preset.Height)
+
+		var buf bytes.Buffer
+		contentType, ext, err := encode(&buf, img)
+		if err != nil {
+			return nil, fmt.Errorf("encode %s variant: %w", preset.Name, err)
+		}
+
+		bounds := img.Bounds()
+		renditions = append(renditions, Rendition{
+			Preset:      preset,
+			Data:        buf.Bytes(),
+			ContentType: contentType,
+			Extension:   ext,
+			Width:       bounds.Dx(),
+			Height:      bounds.Dy(),
+		})
+	}
+
+	return renditions, nil
+}
+
+// Fit scales img down to fit within maxWidth x maxHeight, preserving its aspect ratio
+func Fit(img image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width <= maxWidth && height <= maxHeight {
+		return img
+	}
+
+	scale := min(float64(maxWidth)/float64(width), float64(maxHeight)/float64(height))
+	dstWidth := max(1, int(float64(width)*scale+0.5))
+	dstHeight := max(1, int(float64(height)*scale+0.5))
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// encode writes opaque images as JPEG and keeps PNG for anything with transparency.
+// There is no pure Go WebP encoder among our dependencies, so WebP sources are
+// converted as well.
+func encode(w io.Writer, img image.Image) (string, string, error) {
+	if isOpaque(img) {
+		return "image/jpeg", ".jpg", jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	return "image/png", ".png", png.Encode(w, img)
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
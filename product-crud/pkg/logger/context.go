@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type contextKey struct{}
+
+// nop is returned by FromContext when the context carries no logger
+var nop = &Logger{zap: zap.NewNop(), level: zap.NewAtomicLevel(), sinks: &sinks{}, redactor: mustRedactor()}
+
+// Nop returns a logger discarding everything, for components given no logger
+func Nop() *Logger {
+	return nop
+}
+
+func mustRedactor() *Redactor {
+	r, err := NewRedactor(RedactionConfig{})
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// NewContext returns a copy of ctx carrying l, typically a child logger enriched with the
+// request ID and route of the request ctx belongs to
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, or one discarding everything
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return l
+	}
+	return nop
+}
+
+// Ctx returns the logger carried by ctx, falling back to l outside of a request
+func (l *Logger) Ctx(ctx context.Context) *Logger {
+	if child, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return child
+	}
+	return l
+}
+
+// TraceID extracts the trace ID from a W3C traceparent header, such as
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", or returns ""
+func TraceID(traceparent string) string {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || strings.Trim(parts[1], "0") == "" {
+		return ""
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return ""
+	}
+	return strings.ToLower(parts[1])
+}
@@ -0,0 +1,44 @@
+package logger
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Field is a key and a typed value added to a log entry
+type Field = zap.Field
+
+func String(key, value string) Field {
+	return zap.String(key, value)
+}
+
+func Int(key string, value int) Field {
+	return zap.Int(key, value)
+}
+
+func Int64(key string, value int64) Field {
+	return zap.Int64(key, value)
+}
+
+func Bool(key string, value bool) Field {
+	return zap.Bool(key, value)
+}
+
+func Time(key string, value time.Time) Field {
+	return zap.Time(key, value)
+}
+
+func Duration(key string, value time.Duration) Field {
+	return zap.Duration(key, value)
+}
+
+// Err adds err under the "error" key
+func Err(err error) Field {
+	return zap.Error(err)
+}
+
+// Any adds a value of any other type, such as a recovered panic
+func Any(key string, value interface{}) Field {
+	return zap.Any(key, value)
+}
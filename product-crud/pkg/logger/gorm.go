@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger adapts a Logger to GORM. Statements are logged at debug, those slower than
+// the threshold as warnings and failed ones as errors, each through the logger of the
+// statement's context so they carry its request ID. Bound parameters are left out of
+// the logged SQL, as they may hold personal data.
+type GormLogger struct {
+	base          *Logger
+	slowThreshold time.Duration
+	level         gormlogger.LogLevel
+}
+
+// NewGormLogger returns a GORM logger writing to base; a zero slowThreshold disables the
+// slow query warnings
+func NewGormLogger(base *Logger, slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{
+		base:          base,
+		slowThreshold: slowThreshold,
+		level:         gormlogger.Info,
+	}
+}
+
+// LogMode returns a copy logging at most at level, as used by db.Debug() and Silent sessions
+func (g *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *g
+	clone.level = level
+	return &clone
+}
+
+func (g *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if g.level >= gormlogger.Info {
+		g.base.Ctx(ctx).Info(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (g *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if g.level >= gormlogger.Warn {
+		g.base.Ctx(ctx).Warn(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (g *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if g.level >= gormlogger.Error {
+		g.base.Ctx(ctx).Error(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (g *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if g.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	log := g.base.Ctx(ctx)
+	fields := func() []Field {
+		sql, rows := fc()
+		return []Field{String("sql", sql), Int64("rows", rows), Duration("duration", elapsed)}
+	}
+
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && g.level >= gormlogger.Error:
+		log.Error("SQL query failed", append(fields(), Err(err))...)
+	case g.slowThreshold > 0 && elapsed > g.slowThreshold && g.level >= gormlogger.Warn:
+		log.Warn("Slow SQL query", append(fields(), Duration("threshold", g.slowThreshold))...)
+	case g.level >= gormlogger.Info && log.level.Enabled(zapcore.DebugLevel):
+		log.Debug("SQL query", fields()...)
+	}
+}
+
+// ParamsFilter keeps GORM from inlining the bound parameters into the logged SQL
+func (g *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
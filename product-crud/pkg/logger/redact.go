@@ -0,0 +1,275 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Redacted replaces sensitive values
+const Redacted = "[REDACTED]"
+
+// DefaultRedactedFields are the field, header and JSON property names whose values are
+// always redacted. Names match regardless of case, dashes and underscores.
+var DefaultRedactedFields = []string{
+	"authorization", "proxy_authorization", "cookie", "set_cookie",
+	"password", "passwd", "secret", "client_secret",
+	"token", "access_token", "refresh_token", "id_token", "api_key", "apikey", "x_api_key",
+	"card_number", "cvv", "cvc",
+}
+
+// DefaultRedactedPatterns find sensitive values inside any string. When a pattern has a
+// group named secret only that group is redacted, otherwise the whole match is.
+var DefaultRedactedPatterns = []string{
+	// Bearer and basic credentials, as found in Authorization headers
+	`(?i)\b(?:bearer|basic)\s+(?P<secret>[A-Za-z0-9\-._~+/]+=*)`,
+	// The password of a URL, such as a postgres:// DSN
+	`(?i)\b[a-z][a-z0-9+.\-]*://[^:/@\s]*:(?P<secret>[^@\s]+)@`,
+	// The password of a keyword/value DSN
+	`(?i)\bpassword=(?P<secret>'(?:[^'\\]|\\.)*'|[^\s]+)`,
+	// Cookie headers
+	`(?i)\b(?:set-)?cookie:\s*(?P<secret>[^\r\n]+)`,
+	// Email addresses
+	`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+}
+
+// cardPattern finds candidate card numbers, which are only redacted if their Luhn
+// checksum holds, so IDs and timestamps are left alone
+var cardPattern = regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`)
+
+// RedactionConfig adds to the default redacted field names and patterns
+type RedactionConfig struct {
+	Fields   []string
+	Patterns []string
+}
+
+// Redactor masks sensitive values in log entries and response payloads
+type Redactor struct {
+	fields   map[string]struct{}
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor builds a redactor applying the defaults and cfg
+func NewRedactor(cfg RedactionConfig) (*Redactor, error) {
+	r := &Redactor{fields: make(map[string]struct{})}
+
+	for _, names := range [][]string{DefaultRedactedFields, cfg.Fields} {
+		for _, name := range names {
+			r.fields[normalizeField(name)] = struct{}{}
+		}
+	}
+
+	patterns := append(slices.Clone(DefaultRedactedPatterns), cfg.Patterns...)
+	for _, expr := range patterns {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", expr, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	return r, nil
+}
+
+// Sensitive reports whether values named name are always redacted
+func (r *Redactor) Sensitive(name string) bool {
+	_, ok := r.fields[normalizeField(name)]
+	return ok
+}
+
+// String redacts whatever the patterns find in s
+func (r *Redactor) String(s string) string {
+	for _, re := range r.patterns {
+		s = redactMatches(re, s)
+	}
+
+	return cardPattern.ReplaceAllStringFunc(s, func(match string) string {
+		if luhn(match) {
+			return Redacted
+		}
+		return match
+	})
+}
+
+// JSON redacts a JSON document: the values of sensitive properties and whatever the
+// patterns find in strings. Invalid JSON is redacted as a plain string.
+func (r *Redactor) JSON(data []byte) []byte {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return []byte(r.String(string(data)))
+	}
+
+	redacted, err := json.Marshal(r.value(doc))
+	if err != nil {
+		return data
+	}
+	return redacted
+}
+
+// Query redacts a URL query string: the values of sensitive parameters and whatever the
+// patterns find in the others
+func (r *Redactor) Query(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return r.String(raw)
+	}
+
+	// Encoded by hand so the placeholder stays readable
+	var b strings.Builder
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		for _, item := range values[key] {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(url.QueryEscape(key))
+			b.WriteByte('=')
+			if r.Sensitive(key) {
+				b.WriteString(Redacted)
+			} else {
+				b.WriteString(strings.ReplaceAll(url.QueryEscape(r.String(item)), url.QueryEscape(Redacted), Redacted))
+			}
+		}
+	}
+	return b.String()
+}
+
+// value redacts a decoded JSON value
+func (r *Redactor) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return r.String(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = r.value(v[i])
+		}
+		return v
+	case map[string]interface{}:
+		for key, item := range v {
+			if r.Sensitive(key) {
+				v[key] = Redacted
+			} else {
+				v[key] = r.value(item)
+			}
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// field redacts a log field, keeping its type where it cannot hold text
+func (r *Redactor) field(f Field) Field {
+	if r.Sensitive(f.Key) {
+		return zap.String(f.Key, Redacted)
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		return zap.String(f.Key, r.String(f.String))
+	case zapcore.ByteStringType:
+		return zap.String(f.Key, r.String(string(f.Interface.([]byte))))
+	case zapcore.ErrorType:
+		return zap.String(f.Key, r.String(f.Interface.(error).Error()))
+	case zapcore.StringerType:
+		return zap.String(f.Key, r.String(fmt.Sprint(f.Interface)))
+	case zapcore.ReflectType:
+		data, err := json.Marshal(f.Interface)
+		if err != nil {
+			return zap.String(f.Key, r.String(fmt.Sprint(f.Interface)))
+		}
+		return zap.Reflect(f.Key, json.RawMessage(r.JSON(data)))
+	default:
+		return f
+	}
+}
+
+func (r *Redactor) fieldList(fields []Field) []Field {
+	redacted := make([]Field, len(fields))
+	for i, f := range fields {
+		redacted[i] = r.field(f)
+	}
+	return redacted
+}
+
+// redactMatches replaces the matches of re in s, or only their secret group if re has one
+func redactMatches(re *regexp.Regexp, s string) string {
+	group := re.SubexpIndex("secret")
+	if group < 0 {
+		return re.ReplaceAllString(s, Redacted)
+	}
+
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[2*group], m[2*group+1]
+		if start < 0 {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(Redacted)
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func normalizeField(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "-", "_")
+}
+
+// luhn reports whether the digits of s pass the Luhn checksum of card numbers
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// redactingCore redacts the message and fields of every entry before passing it on
+type redactingCore struct {
+	zapcore.Core
+	redactor *Redactor
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(c.redactor.fieldList(fields)), redactor: c.redactor}
+}
+
+func (c *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = c.redactor.String(entry.Message)
+	return c.Core.Write(entry, c.redactor.fieldList(fields))
+}
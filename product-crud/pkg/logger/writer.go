@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"io"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Level is the severity of an entry
+type Level = zapcore.Level
+
+const (
+	DebugLevel = zapcore.DebugLevel
+	InfoLevel  = zapcore.InfoLevel
+	WarnLevel  = zapcore.WarnLevel
+	ErrorLevel = zapcore.ErrorLevel
+)
+
+// Writer returns an io.Writer logging each write as one entry at level, for libraries
+// such as gin that only print
+func (l *Logger) Writer(level Level) io.Writer {
+	stdLog, err := zap.NewStdLogAt(l.zap, level)
+	if err != nil {
+		// Only reachable with a level outside the constants above
+		return io.Discard
+	}
+	return stdLog.Writer()
+}
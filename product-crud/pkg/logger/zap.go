@@ -1,82 +1,229 @@
 package logger
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
-	"sync"
+	"path/filepath"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-var (
-	instance *Logger
-	once     sync.Once
-)
+// Config selects the level and where entries are written. At least one sink is required.
+type Config struct {
+	// Level is debug, info, warn or error; empty means info
+	Level string
+	// Stdout is the format of entries written to standard output, json or console;
+	// empty writes nothing there
+	Stdout string
+	File   FileConfig
+	Syslog SyslogConfig
+	// Redaction adds to the field names and patterns redacted from every entry
+	Redaction RedactionConfig
+}
 
+// FileConfig writes JSON entries to a file, rotated once it reaches MaxSizeMB. Rotated
+// files are removed after MaxAgeDays or beyond MaxBackups, zero keeping them all. An empty
+// Path disables the file.
+type FileConfig struct {
+	Path       string
+	MaxSizeMB  int
+	MaxAgeDays int
+	MaxBackups int
+	Compress   bool
+}
+
+// SyslogConfig sends JSON entries to syslog at the matching priority. An empty Network
+// dials the local daemon.
+type SyslogConfig struct {
+	Enabled bool
+	Network string
+	Addr    string
+	Tag     string
+}
+
+// Logger writes structured entries. Fields are typed, so unlike key/value pairs they
+// cannot be misaligned or mistaken for printf arguments. A logger and the children made
+// by With share one level, which SetLevel changes at runtime. Sensitive values are
+// redacted from messages and fields before any sink sees them.
 type Logger struct {
-	zap *zap.SugaredLogger
-}
-
-func NewLogger(level string) *Logger {
-	once.Do(func() {
-		encoderConfig := zap.NewProductionEncoderConfig()
-		encoderConfig.TimeKey = "timestamp"
-		encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-
-		var zapLevel zapcore.Level
-		switch level {
-		case "debug":
-			zapLevel = zapcore.DebugLevel
-		case "info":
-			zapLevel = zapcore.InfoLevel
-		case "warn":
-			zapLevel = zapcore.WarnLevel
-		case "error":
-			zapLevel = zapcore.ErrorLevel
-		default:
-			zapLevel = zapcore.InfoLevel
+	zap      *zap.Logger
+	level    zap.AtomicLevel
+	sinks    *sinks
+	redactor *Redactor
+}
+
+// sinks holds what Rotate and Close act on
+type sinks struct {
+	file    *lumberjack.Logger
+	closers []io.Closer
+}
+
+// New builds a logger writing to the sinks cfg enables
+func New(cfg Config) (*Logger, error) {
+	level, err := parseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	atomicLevel := zap.NewAtomicLevelAt(level)
+
+	redactor, err := NewRedactor(cfg.Redaction)
+	if err != nil {
+		return nil, err
+	}
+
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = "timestamp"
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	s := &sinks{}
+	var cores []zapcore.Core
+
+	switch cfg.Stdout {
+	case "":
+	case "json":
+		cores = append(cores, zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), zapcore.Lock(os.Stdout), atomicLevel))
+	case "console":
+		cores = append(cores, zapcore.NewCore(zapcore.NewConsoleEncoder(encoderConfig), zapcore.Lock(os.Stdout), atomicLevel))
+	default:
+		return nil, fmt.Errorf("invalid stdout log format %q, want json or console", cfg.Stdout)
+	}
+
+	if cfg.File.Path != "" {
+		if err := checkWritable(cfg.File.Path); err != nil {
+			return nil, err
+		}
+		s.file = &lumberjack.Logger{
+			Filename:   cfg.File.Path,
+			MaxSize:    cfg.File.MaxSizeMB,
+			MaxAge:     cfg.File.MaxAgeDays,
+			MaxBackups: cfg.File.MaxBackups,
+			Compress:   cfg.File.Compress,
+			LocalTime:  true,
 		}
+		s.closers = append(s.closers, s.file)
+		cores = append(cores, zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), zapcore.AddSync(s.file), atomicLevel))
+	}
 
-		logFile, _ := os.OpenFile("log/log.txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-
-		core := zapcore.NewTee(
-			zapcore.NewCore(
-				zapcore.NewJSONEncoder(encoderConfig),
-				zapcore.AddSync(logFile),
-				zapLevel,
-			),
-			zapcore.NewCore(
-				zapcore.NewConsoleEncoder(encoderConfig),
-				zapcore.AddSync(os.Stdout),
-				zapLevel,
-			),
-		)
-
-		logger := zap.New(core)
-		instance = &Logger{
-			zap: logger.Sugar(),
+	if cfg.Syslog.Enabled {
+		core, closer, err := newSyslogCore(cfg.Syslog, zapcore.NewJSONEncoder(encoderConfig), atomicLevel)
+		if err != nil {
+			s.close()
+			return nil, err
 		}
-	})
+		s.closers = append(s.closers, closer)
+		cores = append(cores, core)
+	}
+
+	if len(cores) == 0 {
+		return nil, errors.New("no log sink configured")
+	}
+
+	return &Logger{
+		zap:      zap.New(&redactingCore{Core: zapcore.NewTee(cores...), redactor: redactor}),
+		level:    atomicLevel,
+		sinks:    s,
+		redactor: redactor,
+	}, nil
+}
+
+// SetLevel changes the level of the logger and of every logger sharing it
+func (l *Logger) SetLevel(level string) error {
+	if level == "" {
+		return errors.New("log level is required")
+	}
+	zapLevel, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.level.SetLevel(zapLevel)
+	return nil
+}
+
+// Level returns the current level
+func (l *Logger) Level() string {
+	return l.level.Level().String()
+}
+
+// Redactor returns the redactor applied to entries, for payloads leaving by other ways
+func (l *Logger) Redactor() *Redactor {
+	return l.redactor
+}
+
+// Rotate starts a new log file, for use after the current one was moved away
+func (l *Logger) Rotate() error {
+	if l.sinks.file == nil {
+		return nil
+	}
+	return l.sinks.file.Rotate()
+}
 
-	return instance
+// Close flushes buffered entries and closes the file and syslog connection
+func (l *Logger) Close() error {
+	_ = l.zap.Sync()
+	return l.sinks.close()
 }
 
-func (l *Logger) Debug(msg string, keysAndValues ...interface{}) {
-	l.zap.Debugw(msg, keysAndValues...)
+func (s *sinks) close() error {
+	var errs []error
+	for _, c := range s.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
 }
 
-func (l *Logger) Info(msg string, keysAndValues ...interface{}) {
-	l.zap.Infow(msg, keysAndValues...)
+// With returns a child logger that adds fields to every entry
+func (l *Logger) With(fields ...Field) *Logger {
+	return &Logger{zap: l.zap.With(fields...), level: l.level, sinks: l.sinks, redactor: l.redactor}
 }
 
-func (l *Logger) Warn(msg string, keysAndValues ...interface{}) {
-	l.zap.Warnw(msg, keysAndValues...)
+func (l *Logger) Debug(msg string, fields ...Field) {
+	l.zap.Debug(msg, fields...)
 }
 
-func (l *Logger) Error(msg string, keysAndValues ...interface{}) {
-	l.zap.Errorw(msg, keysAndValues...)
+func (l *Logger) Info(msg string, fields ...Field) {
+	l.zap.Info(msg, fields...)
 }
 
-func (l *Logger) Fatal(msg string, keysAndValues ...interface{}) {
-	l.zap.Fatalw(msg, keysAndValues...)
-}
\ No newline at end of file
+func (l *Logger) Warn(msg string, fields ...Field) {
+	l.zap.Warn(msg, fields...)
+}
+
+func (l *Logger) Error(msg string, fields ...Field) {
+	l.zap.Error(msg, fields...)
+}
+
+func (l *Logger) Fatal(msg string, fields ...Field) {
+	l.zap.Fatal(msg, fields...)
+}
+
+func parseLevel(level string) (zapcore.Level, error) {
+	switch level {
+	case "debug":
+		return zapcore.DebugLevel, nil
+	case "", "info":
+		return zapcore.InfoLevel, nil
+	case "warn":
+		return zapcore.WarnLevel, nil
+	case "error":
+		return zapcore.ErrorLevel, nil
+	default:
+		return zapcore.InfoLevel, fmt.Errorf("invalid log level %q, want debug, info, warn or error", level)
+	}
+}
+
+// checkWritable creates the log file and its directory if needed, so a bad path fails
+// at startup rather than on the first entry
+func checkWritable(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("log file: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("log file: %w", err)
+	}
+	return f.Close()
+}
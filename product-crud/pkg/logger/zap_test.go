@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newFileLogger returns a logger writing JSON entries to a file of its own
+func newFileLogger(t *testing.T, level string) (*Logger, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	l, err := New(Config{Level: level, File: FileConfig{Path: path}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	return l, path
+}
+
+// readEntries returns the entries written to path so far
+func readEntries(t *testing.T, l *Logger, path string) []map[string]interface{} {
+	t.Helper()
+	_ = l.zap.Sync()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open log file: %v", err)
+	}
+	defer f.Close()
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid entry %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	if _, err := New(Config{}); err == nil {
+		t.Error("New without a sink succeeded, want an error")
+	}
+	if _, err := New(Config{Level: "verbose", Stdout: "json"}); err == nil {
+		t.Error("New with an invalid level succeeded, want an error")
+	}
+	if _, err := New(Config{Stdout: "xml"}); err == nil {
+		t.Error("New with an invalid stdout format succeeded, want an error")
+	}
+}
+
+func TestLoggersAreIndependent(t *testing.T) {
+	first, firstPath := newFileLogger(t, "info")
+	second, secondPath := newFileLogger(t, "info")
+
+	if err := first.SetLevel("error"); err != nil {
+		t.Fatalf("SetLevel: %v", err)
+	}
+	first.Info("dropped")
+	second.Info("kept", String("product_id", "1"))
+
+	if entries := readEntries(t, first, firstPath); len(entries) != 0 {
+		t.Errorf("first logger wrote %v, want nothing below error", entries)
+	}
+
+	entries := readEntries(t, second, secondPath)
+	if len(entries) != 1 || entries[0]["msg"] != "kept" || entries[0]["product_id"] != "1" {
+		t.Fatalf("second logger wrote %v, want the kept entry", entries)
+	}
+	if second.Level() != "info" {
+		t.Errorf("second level = %s, want info", second.Level())
+	}
+}
+
+func TestChildrenShareLevel(t *testing.T) {
+	l, path := newFileLogger(t, "info")
+	child := l.With(String("request_id", "abc"))
+
+	child.Debug("dropped")
+	if err := l.SetLevel("debug"); err != nil {
+		t.Fatalf("SetLevel: %v", err)
+	}
+	child.Debug("kept")
+
+	entries := readEntries(t, l, path)
+	if len(entries) != 1 || entries[0]["msg"] != "kept" || entries[0]["request_id"] != "abc" {
+		t.Fatalf("entries = %v, want the kept debug entry with its request ID", entries)
+	}
+}
+
+func TestSetLevelRejectsInvalidLevels(t *testing.T) {
+	l, _ := newFileLogger(t, "warn")
+
+	for _, level := range []string{"", "verbose"} {
+		if err := l.SetLevel(level); err == nil {
+			t.Errorf("SetLevel(%q) succeeded, want an error", level)
+		}
+	}
+	if l.Level() != "warn" {
+		t.Errorf("level = %s after invalid changes, want warn", l.Level())
+	}
+}
+
+func TestRotate(t *testing.T) {
+	l, path := newFileLogger(t, "info")
+
+	l.Info("before")
+	if err := l.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	l.Info("after")
+
+	files, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("read log dir: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("log dir holds %d files, want the current one and a backup", len(files))
+	}
+	if entries := readEntries(t, l, path); len(entries) != 1 || entries[0]["msg"] != "after" {
+		t.Fatalf("current file holds %v, want only the entry written after rotating", entries)
+	}
+}
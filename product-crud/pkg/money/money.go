@@ -0,0 +1,63 @@
+package money
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultCurrency is used for products created without an explicit currency
+const DefaultCurrency = "USD"
+
+// MaxScale is the number of fractional digits kept by the NUMERIC storage columns
+const MaxScale = 4
+
+// minorUnits lists ISO-4217 currencies whose minor unit differs from two digits
+var minorUnits = map[string]int32{
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	"CLF": 4, "UYW": 4,
+}
+
+// Money is an exact amount in a given ISO-4217 currency.
+// Amounts are encoded as JSON strings so clients never round-trip them through floats.
+type Money struct {
+	Amount   decimal.Decimal `json:"amount" swaggertype:"string" example:"19.99" validate:"positive,currencyscale=Currency"`
+	Currency string          `json:"currency" example:"USD" validate:"required,iso4217"`
+}
+
+// New builds a Money value, normalizing the currency code to upper case
+func New(amount decimal.Decimal, currency string) Money {
+	return Money{
+		Amount:   amount,
+		Currency: NormalizeCurrency(currency),
+	}
+}
+
+// NormalizeCurrency upper-cases a currency code and falls back to DefaultCurrency when empty
+func NormalizeCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return currency
+}
+
+// Scale returns the number of minor-unit digits of the currency, read as NormalizeCurrency does
+func Scale(currency string) int32 {
+	if scale, ok := minorUnits[NormalizeCurrency(currency)]; ok {
+		return scale
+	}
+	return 2
+}
+
+// FitsScale reports whether the amount can be represented in the currency without rounding
+func FitsScale(amount decimal.Decimal, currency string) bool {
+	return amount.Equal(amount.Truncate(Scale(currency)))
+}
+
+func (m Money) String() string {
+	return m.Amount.StringFixed(Scale(m.Currency)) + " " + m.Currency
+}
@@ -0,0 +1,74 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestNormalizeCurrency(t *testing.T) {
+	tests := map[string]string{
+		"USD":   "USD",
+		"eur":   "EUR",
+		" jpy ": "JPY",
+		"":      DefaultCurrency,
+		"  ":    DefaultCurrency,
+	}
+
+	for currency, want := range tests {
+		if got := NormalizeCurrency(currency); got != want {
+			t.Errorf("NormalizeCurrency(%q) = %q, want %q", currency, got, want)
+		}
+	}
+}
+
+func TestScale(t *testing.T) {
+	tests := map[string]int32{
+		"USD":   2,
+		"":      2,
+		"JPY":   0,
+		"jpy":   0,
+		" jpy ": 0,
+		"BHD":   3,
+		"CLF":   4,
+		"XYZ":   2,
+	}
+
+	for currency, want := range tests {
+		if got := Scale(currency); got != want {
+			t.Errorf("Scale(%q) = %d, want %d", currency, got, want)
+		}
+	}
+}
+
+func TestFitsScale(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		want     bool
+	}{
+		{amount: "19.99", currency: "USD", want: true},
+		{amount: "19.999", currency: "USD", want: false},
+		{amount: "19.90", currency: "USD", want: true},
+		{amount: "1999", currency: "JPY", want: true},
+		{amount: "1999.5", currency: "JPY", want: false},
+		{amount: "1999.5", currency: " jpy ", want: false},
+		{amount: "1.999", currency: "BHD", want: true},
+	}
+
+	for _, tt := range tests {
+		if got := FitsScale(decimal.RequireFromString(tt.amount), tt.currency); got != tt.want {
+			t.Errorf("FitsScale(%s, %q) = %v, want %v", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestNewNormalizesCurrency(t *testing.T) {
+	m := New(decimal.RequireFromString("1999"), " jpy ")
+	if m.Currency != "JPY" {
+		t.Errorf("Currency = %q, want JPY", m.Currency)
+	}
+	if got := m.String(); got != "1999 JPY" {
+		t.Errorf("String() = %q, want %q", got, "1999 JPY")
+	}
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Blob stores objects in a bucket of an S3-compatible service such as AWS S3 or MinIO
+type S3Blob struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Blob connects to the endpoint and verifies that the bucket exists
+func NewS3Blob(endpoint, region, bucket, accessKey, secretKey string, useSSL bool) (*S3Blob, error) {
+	if endpoint == "" || bucket == "" {
+		return nil, errors.New("s3 storage requires an endpoint and a bucket")
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
+		Region: region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := client.BucketExists(context.Background(), bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("s3 bucket " + bucket + " does not exist")
+	}
+
+	return &S3Blob{
+		client: client,
+		bucket: bucket,
+	}, nil
+}
+
+func (b *S3Blob) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := b.client.PutObject(ctx, b.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return err
+}
+
+func (b *S3Blob) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	obj, err := b.client.GetObject(ctx, b.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, translateS3Error(err)
+	}
+
+	// GetObject is lazy; Stat performs the request and surfaces missing keys
+	info, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, nil, translateS3Error(err)
+	}
+
+	return obj, &Object{
+		Key:          key,
+		ContentType:  info.ContentType,
+		Size:         info.Size,
+		LastModified: info.LastModified,
+	}, nil
+}
+
+func (b *S3Blob) Delete(ctx context.Context, key string) error {
+	return translateS3Error(b.client.RemoveObject(ctx, b.bucket, key, minio.RemoveObjectOptions{}))
+}
+
+func translateS3Error(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned when a key does not exist in the store
+var ErrNotFound = errors.New("blob not found")
+
+// Object describes a stored blob
+type Object struct {
+	Key          string
+	ContentType  string
+	Size         int64
+	LastModified time.Time
+}
+
+// Blob stores opaque objects under slash-separated keys
+type Blob interface {
+	// Put writes size bytes from r under key, replacing any existing object
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key; the caller must close the reader
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	// Delete removes the object stored under key; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// Config selects and configures a Blob driver
+type Config struct {
+	Driver string
+
+	LocalDir string
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3UseSSL    bool
+}
+
+// New creates the Blob driver named by cfg.Driver ("local" or "s3")
+func New(cfg Config) (Blob, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocalBlob(cfg.LocalDir)
+	case "s3":
+		return NewS3Blob(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKey, cfg.S3SecretKey, cfg.S3UseSSL)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}